@@ -0,0 +1,144 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Analysis is the result of Program.Analyze().
+type Analysis struct {
+	// UnusedResults are results referenced by neither arguments nor hooks.
+	UnusedResults []UnusedResult
+
+	// DeadFunctionNames are names of DI Functions which are not roots and whose results are
+	// unused, or used only by other dead DI Functions, directly or indirectly.
+	// DI Functions without results are always regarded as roots.
+	DeadFunctionNames []string
+}
+
+// UnusedResult represents a result referenced by neither arguments nor hooks.
+type UnusedResult struct {
+	ValueName    string
+	FunctionName string
+}
+
+// Analyze detects unused results and dead DI Functions in the Program. Values which cannot be
+// resolved are ignored.
+func (p *Program) Analyze() Analysis {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_ = p.resolve()
+	indexes := p.analyze()
+	var analysis Analysis
+	for _, resultIndex := range indexes.UnusedResultIndexes {
+		result := &p.results[resultIndex]
+		analysis.UnusedResults = append(analysis.UnusedResults, UnusedResult{
+			ValueName:    result.ValueName,
+			FunctionName: p.functions[result.FunctionIndex].Name,
+		})
+	}
+	for _, functionIndex := range indexes.DeadFunctionIndexes {
+		analysis.DeadFunctionNames = append(analysis.DeadFunctionNames, p.functions[functionIndex].Name)
+	}
+	sort.Slice(analysis.UnusedResults, func(i, j int) bool {
+		return analysis.UnusedResults[i].ValueName < analysis.UnusedResults[j].ValueName
+	})
+	sort.Strings(analysis.DeadFunctionNames)
+	return analysis
+}
+
+type analysis struct {
+	UnusedResultIndexes []int
+	DeadFunctionIndexes []int
+}
+
+func (p *Program) analyze() analysis {
+	var analysis analysis
+	isResultUsed := make([]bool, len(p.results))
+	for argumentIndex := range p.arguments {
+		argument := &p.arguments[argumentIndex]
+		if argument.ResultIndex >= 0 {
+			isResultUsed[argument.ResultIndex] = true
+		}
+	}
+	hookIndex2ResultIndex := make(map[int]int, len(p.hooks))
+	for resultIndex := range p.results {
+		result := &p.results[resultIndex]
+		for _, hookIndex := range result.HookIndexes {
+			hookIndex2ResultIndex[hookIndex] = resultIndex
+		}
+		if len(result.HookIndexes) >= 1 {
+			isResultUsed[resultIndex] = true
+		}
+	}
+	for resultIndex, isUsed := range isResultUsed {
+		if !isUsed && p.isActive(p.results[resultIndex].FunctionIndex) {
+			analysis.UnusedResultIndexes = append(analysis.UnusedResultIndexes, resultIndex)
+		}
+	}
+	isFunctionLive := make([]bool, len(p.functions))
+	var markLive func(int)
+	markLive = func(functionIndex int) {
+		if isFunctionLive[functionIndex] {
+			return
+		}
+		isFunctionLive[functionIndex] = true
+		function := &p.functions[functionIndex]
+		for _, argumentIndex := range function.ArgumentIndexes {
+			argument := &p.arguments[argumentIndex]
+			if argument.ResultIndex >= 0 {
+				markLive(p.results[argument.ResultIndex].FunctionIndex)
+			}
+		}
+		for _, hookIndex := range function.HookIndexes {
+			if resultIndex, ok := hookIndex2ResultIndex[hookIndex]; ok {
+				markLive(p.results[resultIndex].FunctionIndex)
+			}
+		}
+	}
+	for functionIndex := range p.functions {
+		function := &p.functions[functionIndex]
+		if function.isRoot() {
+			markLive(functionIndex)
+		}
+	}
+	for functionIndex, isLive := range isFunctionLive {
+		if !isLive && p.isActive(functionIndex) {
+			analysis.DeadFunctionIndexes = append(analysis.DeadFunctionIndexes, functionIndex)
+		}
+	}
+	return analysis
+}
+
+func (a analysis) err(p *Program) error {
+	var errs WiringErrors
+	for _, resultIndex := range a.UnusedResultIndexes {
+		result := &p.results[resultIndex]
+		function := &p.functions[result.FunctionIndex]
+		if function.IsRoot {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%w; valueName=%q functionName=%q location=%q",
+			ErrUnusedResult, result.ValueName, function.Name, result.Location))
+	}
+	for _, functionIndex := range a.DeadFunctionIndexes {
+		function := &p.functions[functionIndex]
+		errs = append(errs, fmt.Errorf("%w; functionName=%q location=%q", ErrDeadFunction, function.Name, function.Location))
+	}
+	if len(errs) >= 1 {
+		errs.sort()
+		return errs
+	}
+	return nil
+}
+
+var (
+	// ErrUnusedResult is returned by Program.Validate()/Program.Run() in the strict mode when a result
+	// of a non-root DI Function is referenced by neither arguments nor hooks.
+	ErrUnusedResult = errors.New("di: unused result")
+
+	// ErrDeadFunction is returned by Program.Validate()/Program.Run() in the strict mode when a dead
+	// DI Function is detected.
+	ErrDeadFunction = errors.New("di: dead function")
+)
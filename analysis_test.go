@@ -0,0 +1,85 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/go-tk/testcase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_Analyze(t *testing.T) {
+	type C struct {
+		p *Program
+
+		analysis Analysis
+		errStr   string
+	}
+	tc := testcase.New(func(t *testing.T, c *C) {
+		var p Program
+		c.p = &p
+
+		testcase.Callback(t, "0")
+
+		analysis := p.Analyze()
+		assert.Equal(t, c.analysis, analysis)
+		p.RunOptions.StrictMode = true
+		err := p.Validate()
+		if c.errStr == "" {
+			assert.NoError(t, err)
+		} else {
+			assertEqualError(t, err, c.errStr)
+		}
+	})
+
+	newFunction := func(p *Program, name string, builders ...FunctionBuilder) {
+		builders = append(builders, Body(func(context.Context) error { return nil }))
+		if err := p.DoNewFunction(name, builders...); err != nil {
+			panic(err)
+		}
+	}
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		var x, y int
+		newFunction(c.p, "F1", Result("x", &x))
+		newFunction(c.p, "F2", Argument("x", &x), Result("y", &y))
+		newFunction(c.p, "F3", OptionalArgument("y", &y))
+	}).RunParallel(t)
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		var x, y, z int
+		newFunction(c.p, "F1", Result("x", &x))
+		newFunction(c.p, "F2", Argument("x", &x), Result("y", &y), Result("z", &z))
+		newFunction(c.p, "F3", Argument("y", &y))
+		c.analysis = Analysis{
+			UnusedResults: []UnusedResult{{ValueName: "z", FunctionName: "F2"}},
+		}
+		c.errStr = ErrUnusedResult.Error() + `; valueName="z" functionName="F2" location="analysis_test.go:*"`
+	}).RunParallel(t)
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		var x, y, z int
+		newFunction(c.p, "F1", Result("x", &x))
+		newFunction(c.p, "F2", Argument("x", &x), Result("y", &y))
+		newFunction(c.p, "F3", Result("z", &z), Hook("y", &y, func(context.Context) error { return nil }))
+		c.analysis = Analysis{
+			UnusedResults:     []UnusedResult{{ValueName: "z", FunctionName: "F3"}},
+			DeadFunctionNames: []string{"F1", "F2", "F3"},
+		}
+		c.errStr = ErrDeadFunction.Error() + `; functionName="F1" location="analysis_test.go:*"` + "\n" +
+			ErrDeadFunction.Error() + `; functionName="F2" location="analysis_test.go:*"` + "\n" +
+			ErrDeadFunction.Error() + `; functionName="F3" location="analysis_test.go:*"` + "\n" +
+			ErrUnusedResult.Error() + `; valueName="z" functionName="F3" location="analysis_test.go:*"`
+	}).RunParallel(t)
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		var x, y, z int
+		newFunction(c.p, "F1", Result("x", &x))
+		newFunction(c.p, "F2", Argument("x", &x), Result("y", &y))
+		newFunction(c.p, "F3", Root(), Result("z", &z), Hook("y", &y, func(context.Context) error { return nil }))
+		c.analysis = Analysis{
+			UnusedResults: []UnusedResult{{ValueName: "z", FunctionName: "F3"}},
+		}
+	}).RunParallel(t)
+}
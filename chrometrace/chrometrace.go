@@ -0,0 +1,201 @@
+// Package chrometrace provides an Observer of Program which records a timeline of
+// Program.Run()/Program.Clean() in the trace event format, which can be loaded into
+// chrome://tracing or Perfetto.
+package chrometrace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-tk/di"
+)
+
+// Recorder is an Observer recording trace events for the resolution of DI Functions, the run
+// of the Program, calls to bodies, hook callbacks and cleanups of DI Functions.
+//
+// Events are put on lanes, which are shown as threads. As DI Functions are called sequentially,
+// all events are put on the same lane for now.
+type Recorder struct {
+	di.NopObserver
+
+	mutex  sync.Mutex
+	events []event
+}
+
+var _ di.Observer = (*Recorder)(nil)
+
+type event struct {
+	Name      string
+	Category  string
+	StartTime time.Time
+	Duration  time.Duration
+	Lane      int
+	Args      map[string]interface{}
+}
+
+const defaultLane = 1
+
+// OnResolveDone implements Observer.OnResolveDone.
+func (r *Recorder) OnResolveDone(_ context.Context, e di.ResolveEvent) {
+	r.addEvent(event{
+		Name:      "resolve",
+		Category:  "resolve",
+		StartTime: e.StartTime,
+		Duration:  e.Duration,
+		Args:      errArgs(nil, e.Err),
+	})
+}
+
+// OnFunctionEnd implements Observer.OnFunctionEnd.
+func (r *Recorder) OnFunctionEnd(_ context.Context, e di.FunctionEvent) {
+	r.addEvent(event{
+		Name:      e.FunctionName,
+		Category:  "body",
+		StartTime: e.StartTime,
+		Duration:  e.Duration,
+		Args: errArgs(map[string]interface{}{
+			"location":   e.Location.String(),
+			"valueRefs":  e.ValueRefs,
+			"valueNames": e.ValueNames,
+		}, e.Err),
+	})
+}
+
+// OnHookEnd implements Observer.OnHookEnd.
+func (r *Recorder) OnHookEnd(_ context.Context, e di.HookEvent) {
+	r.addEvent(event{
+		Name:      e.FunctionName + "@hook:" + e.ValueRef,
+		Category:  "hook",
+		StartTime: e.StartTime,
+		Duration:  e.Duration,
+		Args: errArgs(map[string]interface{}{
+			"location":     e.Location.String(),
+			"valueRef":     e.ValueRef,
+			"producerName": e.ProducerName,
+		}, e.Err),
+	})
+}
+
+// OnCleanupEnd implements Observer.OnCleanupEnd.
+func (r *Recorder) OnCleanupEnd(_ context.Context, e di.FunctionEvent) {
+	r.addEvent(event{
+		Name:      e.FunctionName,
+		Category:  "cleanup",
+		StartTime: e.StartTime,
+		Duration:  e.Duration,
+		Args: map[string]interface{}{
+			"location":   e.Location.String(),
+			"valueNames": e.ValueNames,
+		},
+	})
+}
+
+// OnRunEnd implements Observer.OnRunEnd.
+func (r *Recorder) OnRunEnd(_ context.Context, e di.RunEvent) {
+	r.addEvent(event{
+		Name:      "run",
+		Category:  "run",
+		StartTime: e.StartTime,
+		Duration:  e.Duration,
+		Args: errArgs(map[string]interface{}{
+			"calledFunctionCount": e.CalledFunctionCount,
+		}, e.Err),
+	})
+}
+
+func errArgs(args map[string]interface{}, err error) map[string]interface{} {
+	if err == nil {
+		return args
+	}
+	if args == nil {
+		args = make(map[string]interface{}, 1)
+	}
+	args["error"] = err.Error()
+	return args
+}
+
+func (r *Recorder) addEvent(event event) {
+	event.Lane = defaultLane
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Reset discards all events recorded.
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = nil
+}
+
+type traceFile struct {
+	TraceEvents     []traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+}
+
+type traceEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat,omitempty"`
+	Phase     string                 `json:"ph"`
+	Timestamp float64                `json:"ts"`
+	Duration  float64                `json:"dur,omitempty"`
+	PID       int                    `json:"pid"`
+	TID       int                    `json:"tid"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+// WriteTo writes events recorded to the given writer in the JSON object format of trace events,
+// timestamps are in microseconds relative to the earliest event. It is typically called after
+// Program.Run() and Program.Clean().
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	events := append([]event(nil), r.events...)
+	r.mutex.Unlock()
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].StartTime.Equal(events[j].StartTime) {
+			return events[i].StartTime.Before(events[j].StartTime)
+		}
+		return events[i].Duration > events[j].Duration
+	})
+	traceFile := traceFile{
+		TraceEvents:     make([]traceEvent, 0, len(events)+1),
+		DisplayTimeUnit: "ms",
+	}
+	traceFile.TraceEvents = append(traceFile.TraceEvents, traceEvent{
+		Name:  "thread_name",
+		Phase: "M",
+		PID:   1,
+		TID:   defaultLane,
+		Args:  map[string]interface{}{"name": "di"},
+	})
+	var epoch time.Time
+	if len(events) >= 1 {
+		epoch = events[0].StartTime
+	}
+	for _, event := range events {
+		traceFile.TraceEvents = append(traceFile.TraceEvents, traceEvent{
+			Name:      event.Name,
+			Category:  event.Category,
+			Phase:     "X",
+			Timestamp: microseconds(event.StartTime.Sub(epoch)),
+			Duration:  microseconds(event.Duration),
+			PID:       1,
+			TID:       event.Lane,
+			Args:      event.Args,
+		})
+	}
+	data, err := json.Marshal(traceFile)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func microseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Microsecond)
+}
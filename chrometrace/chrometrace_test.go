@@ -0,0 +1,82 @@
+package chrometrace_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-tk/di"
+	. "github.com/go-tk/di/chrometrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder_WriteTo(t *testing.T) {
+	var recorder Recorder
+	ctx := context.Background()
+	t0 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	location := di.Location{File: "main.go", Line: 10}
+	recorder.OnResolveDone(ctx, di.ResolveEvent{StartTime: t0, Duration: 100 * time.Microsecond})
+	recorder.OnFunctionEnd(ctx, di.FunctionEvent{
+		FunctionName: "provideDB",
+		Location:     location,
+		ValueNames:   []string{"DB"},
+		StartTime:    t0.Add(100 * time.Microsecond),
+		Duration:     1500 * time.Nanosecond,
+	})
+	recorder.OnHookEnd(ctx, di.HookEvent{
+		FunctionName: "decorateDB",
+		ValueRef:     "DB",
+		Location:     location,
+		ProducerName: "provideDB",
+		StartTime:    t0.Add(200 * time.Microsecond),
+		Duration:     time.Millisecond,
+		Err:          errors.New("foo"),
+	})
+	recorder.OnRunEnd(ctx, di.RunEvent{StartTime: t0, Duration: 2 * time.Millisecond, CalledFunctionCount: 1, Err: errors.New("bar")})
+	recorder.OnCleanupEnd(ctx, di.FunctionEvent{
+		FunctionName: "provideDB",
+		Location:     location,
+		ValueNames:   []string{"DB"},
+		StartTime:    t0.Add(3 * time.Millisecond),
+		Duration:     10 * time.Microsecond,
+	})
+	var buffer bytes.Buffer
+	n, err := recorder.WriteTo(&buffer)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(buffer.Len()), n)
+	assert.JSONEq(t, `{
+  "displayTimeUnit": "ms",
+  "traceEvents": [
+    {"name": "thread_name", "ph": "M", "ts": 0, "pid": 1, "tid": 1, "args": {"name": "di"}},
+    {"name": "run", "cat": "run", "ph": "X", "ts": 0, "dur": 2000, "pid": 1, "tid": 1, "args": {"calledFunctionCount": 1, "error": "bar"}},
+    {"name": "resolve", "cat": "resolve", "ph": "X", "ts": 0, "dur": 100, "pid": 1, "tid": 1},
+    {"name": "provideDB", "cat": "body", "ph": "X", "ts": 100, "dur": 1.5, "pid": 1, "tid": 1, "args": {"location": "main.go:10", "valueRefs": null, "valueNames": ["DB"]}},
+    {"name": "decorateDB@hook:DB", "cat": "hook", "ph": "X", "ts": 200, "dur": 1000, "pid": 1, "tid": 1, "args": {"location": "main.go:10", "valueRef": "DB", "producerName": "provideDB", "error": "foo"}},
+    {"name": "provideDB", "cat": "cleanup", "ph": "X", "ts": 3000, "dur": 10, "pid": 1, "tid": 1, "args": {"location": "main.go:10", "valueNames": ["DB"]}}
+  ]
+}`, buffer.String())
+}
+
+func TestRecorder(t *testing.T) {
+	var program di.Program
+	var recorder Recorder
+	program.RunOptions.Observer = &recorder
+	var x int
+	program.MustNewFunction(di.Result("X", &x), di.Body(func(context.Context) error { return nil }), di.Cleanup(func() {}))
+	program.MustNewFunction(di.Argument("X", &x), di.Body(func(context.Context) error { return nil }))
+	program.MustRun(context.Background())
+	program.Clean()
+	var buffer bytes.Buffer
+	_, err := recorder.WriteTo(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, bytes.Count(buffer.Bytes(), []byte(`"ph":`)))
+	recorder.Reset()
+	buffer.Reset()
+	_, err = recorder.WriteTo(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(buffer.Bytes(), []byte(`"ph":`)))
+}
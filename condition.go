@@ -0,0 +1,99 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type condition struct {
+	Predicate func() bool
+
+	// ArgumentIndex is the index of the argument for WhenValue(), or -1 for When().
+	ArgumentIndex int
+
+	Location Location
+}
+
+// When specifies a condition for a DI Function, which is evaluated by Program.Run() when the DI
+// Function is about to be called. If the predicate returns false, the DI Function is disabled,
+// i.e. neither its body, its hook callbacks nor its cleanup is called, and its results are absent:
+// optional arguments referencing them are left unset, and required arguments referencing them make
+// Program.Run() fail with a ProducerDisabledError.
+func When(predicate func() bool) FunctionBuilder {
+	location := callerLocation(1)
+	return func(function *function, program *Program) error {
+		if predicate == nil {
+			return fmt.Errorf("%w: no predicate; functionName=%q location=%q", ErrInvalidCondition, function.Name, location)
+		}
+		function.Conditions = append(function.Conditions, condition{
+			Predicate:     predicate,
+			ArgumentIndex: -1,
+			Location:      location,
+		})
+		return nil
+	}
+}
+
+// WhenValue likes When but the predicate is based on a value, e.g. a feature flag, which is received
+// by the given value receiver before the predicate is called. If the value is absent, the DI
+// Function is disabled as well.
+func WhenValue(valueRef string, rawValueReceiverPtr interface{}, predicate func() bool) FunctionBuilder {
+	location := callerLocation(1)
+	argumentBuilder := argument1(valueRef, rawValueReceiverPtr, false, location)
+	return func(function *function, program *Program) error {
+		if predicate == nil {
+			return fmt.Errorf("%w: no predicate; functionName=%q valueRef=%q location=%q", ErrInvalidCondition, function.Name, valueRef, location)
+		}
+		if err := argumentBuilder(function, program); err != nil {
+			return err
+		}
+		argumentIndex := function.ArgumentIndexes[len(function.ArgumentIndexes)-1]
+		program.arguments[argumentIndex].IsCondition = true
+		function.Conditions = append(function.Conditions, condition{
+			Predicate:     predicate,
+			ArgumentIndex: argumentIndex,
+			Location:      location,
+		})
+		return nil
+	}
+}
+
+// evaluateConditions returns the first condition of the given DI Function which is unmet, or
+// nil if none.
+func (p *Program) evaluateConditions(ctx context.Context, function *function) (*condition, error) {
+	for i := range function.Conditions {
+		condition := &function.Conditions[i]
+		if condition.ArgumentIndex >= 0 {
+			argument := &p.arguments[condition.ArgumentIndex]
+			if argument.ResultIndex >= 0 && p.isDisabled(p.results[argument.ResultIndex].FunctionIndex) {
+				return condition, nil
+			}
+			if argument.ResultIndex < 0 && !argument.InheritedValue.IsValid() {
+				// The value is inherited from a disabled producer.
+				return condition, nil
+			}
+			if err := p.setArgument(ctx, function, argument); err != nil {
+				return nil, err
+			}
+		}
+		if !condition.Predicate() {
+			return condition, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *Program) isDisabled(functionIndex int) bool {
+	_, ok := p.disabledFunctions[functionIndex]
+	return ok
+}
+
+var (
+	// ErrInvalidCondition is returned by Program.NewFunction() when an invalid condition is specified.
+	ErrInvalidCondition = errors.New("di: invalid condition")
+
+	// ErrProducerDisabled is returned by Program.Run() when a value used by Argument() is produced
+	// by a DI Function disabled by a condition.
+	ErrProducerDisabled = errors.New("di: producer disabled")
+)
@@ -0,0 +1,183 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhen(t *testing.T) {
+	var p Program
+	var log []string
+	var cache, db string
+	p.MustDoNewFunction("provideCache", Result("CACHE", &cache), Body(func(context.Context) error {
+		log = append(log, "new cache")
+		cache = "cache"
+		return nil
+	}), Cleanup(func() { log = append(log, "close cache") }), When(func() bool { return false }))
+	p.MustDoNewFunction("watchCache", Hook("CACHE", &cache, func(context.Context) error {
+		log = append(log, "watch cache")
+		return nil
+	}), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("provideDB", Result("DB", &db), Body(func(context.Context) error {
+		log = append(log, "new db")
+		db = "db"
+		return nil
+	}), When(func() bool { return true }))
+	var cache2, db2 string
+	p.MustDoNewFunction("serve", OptionalArgument("CACHE", &cache2), Argument("DB", &db2), Body(func(context.Context) error {
+		log = append(log, "serve")
+		return nil
+	}))
+	p.MustRun(context.Background())
+	assert.Equal(t, []string{"new db", "serve"}, log)
+	assert.Equal(t, "", cache2)
+	assert.Equal(t, "db", db2)
+	state := p.State()
+	if assert.Len(t, state.DisabledFunctionIndexes, 1) {
+		assert.Equal(t, "provideCache", p.Graph().Functions[state.DisabledFunctionIndexes[0]].Name)
+	}
+	assert.Empty(t, state.PendingCleanupIndexes)
+	p.Clean()
+	assert.Equal(t, []string{"new db", "serve"}, log)
+}
+
+func TestWhenValue(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var p Program
+		var flags map[string]bool
+		p.MustDoNewFunction("provideFlags", Result("FLAGS", &flags), Body(func(context.Context) error {
+			flags = map[string]bool{"cache": enabled}
+			return nil
+		}))
+		var flags2 map[string]bool
+		var cache string
+		p.MustDoNewFunction("provideCache", WhenValue("FLAGS", &flags2, func() bool { return flags2["cache"] }),
+			Result("CACHE", &cache), Body(func(context.Context) error {
+				cache = "cache"
+				return nil
+			}))
+		var cache2 string
+		p.MustDoNewFunction("serve", OptionalArgument("CACHE", &cache2), Body(func(context.Context) error { return nil }))
+		p.MustRun(context.Background())
+		if enabled {
+			assert.Equal(t, "cache", cache2)
+			assert.Empty(t, p.State().DisabledFunctionIndexes)
+		} else {
+			assert.Equal(t, "", cache2)
+			assert.Len(t, p.State().DisabledFunctionIndexes, 1)
+		}
+		graph := p.Graph()
+		assert.True(t, graph.Functions[1].IsConditional)
+		assert.True(t, graph.Functions[1].Arguments[0].IsCondition)
+		assert.False(t, graph.Functions[2].IsConditional)
+	}
+}
+
+func TestWhenValue_DisabledProducer(t *testing.T) {
+	var p Program
+	var x, y, z int
+	p.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }), When(func() bool { return false }))
+	p.MustDoNewFunction("provideY", WhenValue("X", &x, func() bool { return true }), Result("Y", &y),
+		Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("useY", OptionalArgument("Y", &z), Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+	assert.Len(t, p.State().DisabledFunctionIndexes, 2)
+}
+
+func TestWhen_Errors(t *testing.T) {
+	var p Program
+	var x int
+	err := p.DoNewFunction("foo", Body(func(context.Context) error { return nil }), When(nil))
+	assertEqualError(t, err, `di: invalid condition: no predicate; functionName="foo" location="condition_test.go:*"`)
+	err = p.DoNewFunction("foo", Body(func(context.Context) error { return nil }), WhenValue("X", &x, nil))
+	assertEqualError(t, err, `di: invalid condition: no predicate; functionName="foo" valueRef="X" location="condition_test.go:*"`)
+	err = p.DoNewFunction("foo", Result("X", &x), Body(func(context.Context) error { return nil }),
+		When(func() bool { return true }), Transient())
+	assertEqualError(t, err, `di: invalid transient: condition specified; functionName="foo" location="condition_test.go:*"`)
+
+	p = Program{}
+	var y int
+	p.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }), When(func() bool { return false }))
+	p.MustDoNewFunction("provideY", WhenValue("X", &x, func() bool { return true }), Result("Y", &y),
+		Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("useX", Argument("X", &x), Body(func(context.Context) error { return nil }))
+	err = p.Run(context.Background())
+	assert.ErrorIs(t, err, ErrProducerDisabled)
+	assertEqualError(t, err, `di: producer disabled; valueRef="X" producerName="provideX" conditionLocation="condition_test.go:*" functionName="useX" location="condition_test.go:*"`)
+
+	p = Program{}
+	var flag bool
+	p.MustDoNewFunction("provideFlag", Result("FLAG", &flag), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("provideX", WhenValue("FLAG", &flag, func() bool { return flag }), Result("X", &x),
+		Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("useX", Argument("X", &y), Body(func(context.Context) error { return nil }))
+	err = p.Run(context.Background())
+	assertEqualError(t, err, `di: producer disabled; valueRef="X" producerName="provideX" conditionLocation="condition_test.go:*" conditionValueRef="FLAG" functionName="useX" location="condition_test.go:*"`)
+}
+
+func TestWhenValue_Include(t *testing.T) {
+	var a Program
+	var padA int
+	a.MustDoNewFunction("padA", Argument("PAD", &padA), Body(func(context.Context) error { return nil }))
+	var pad int
+	a.MustDoNewFunction("providePad", Result("PAD", &pad), Body(func(context.Context) error {
+		pad = 1
+		return nil
+	}))
+	var b Program
+	var flag, flag2 bool
+	b.MustDoNewFunction("provideFlag", Result("FLAG", &flag), Body(func(context.Context) error {
+		flag = true
+		return nil
+	}))
+	var x int
+	b.MustDoNewFunction("provideX", WhenValue("FLAG", &flag2, func() bool { return flag2 }), Result("X", &x),
+		Body(func(context.Context) error {
+			x = 100
+			return nil
+		}))
+	var x2 int
+	b.MustDoNewFunction("useX", Argument("X", &x2), Body(func(context.Context) error { return nil }))
+	a.MustInclude(&b)
+	a.MustRun(context.Background())
+	assert.Equal(t, 1, padA)
+	assert.True(t, flag2)
+	assert.Equal(t, 100, x2)
+	assert.Empty(t, a.State().DisabledFunctionIndexes)
+}
+
+func TestWhen_Inherited(t *testing.T) {
+	var p Program
+	var cache string
+	p.MustDoNewFunction("provideCache", Result("CACHE", &cache), Body(func(context.Context) error {
+		cache = "cache"
+		return nil
+	}), When(func() bool { return false }))
+	p.MustRun(context.Background())
+
+	scope := p.NewScope()
+	var cache2, cache3, y string
+	var isHooked bool
+	scope.MustDoNewFunction("useCache", OptionalArgument("CACHE", &cache2), Body(func(context.Context) error { return nil }))
+	scope.MustDoNewFunction("watchCache", Hook("CACHE", &cache3, func(context.Context) error {
+		isHooked = true
+		return nil
+	}), Body(func(context.Context) error { return nil }))
+	scope.MustDoNewFunction("provideY", WhenValue("CACHE", &cache3, func() bool { return true }), Result("Y", &y),
+		Body(func(context.Context) error { return nil }))
+	scope.MustRun(context.Background())
+	assert.Equal(t, "", cache2)
+	assert.False(t, isHooked)
+	if assert.Len(t, scope.State().DisabledFunctionIndexes, 1) {
+		assert.Equal(t, "provideY", scope.Graph().Functions[scope.State().DisabledFunctionIndexes[0]].Name)
+	}
+
+	scope = p.NewScope()
+	scope.MustDoNewFunction("useCache", Argument("CACHE", &cache2), Body(func(context.Context) error { return nil }))
+	err := scope.Validate()
+	assert.ErrorIs(t, err, ErrProducerDisabled)
+	assertEqualError(t, err, `di: producer disabled; valueRef="CACHE" producerName="provideCache" conditionLocation="condition_test.go:*" functionName="useCache" location="condition_test.go:*"`)
+}
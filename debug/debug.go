@@ -0,0 +1,246 @@
+// Package debug provides an HTTP handler exposing the wiring and the execution state of a
+// Program, in the manner of net/http/pprof.
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"github.com/go-tk/di"
+)
+
+// Handler returns an HTTP handler serving the wiring and the execution state of the given
+// Program, which is safe to call concurrently with Program.Run()/Program.Clean().
+//
+// The view is selected by the query parameter "format": "html" (default), "json" or "dot".
+func Handler(program *di.Program) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "html":
+			serveHTML(w, newSnapshot(program))
+		case "json":
+			serveJSON(w, newSnapshot(program))
+		case "dot":
+			serveDOT(w, program)
+		default:
+			http.Error(w, "unknown format: "+format, http.StatusBadRequest)
+		}
+	})
+}
+
+type snapshot struct {
+	IsRunning           bool       `json:"isRunning"`
+	CalledFunctionCount int        `json:"calledFunctionCount"`
+	TotalDuration       string     `json:"totalDuration"`
+	RunError            string     `json:"runError,omitempty"`
+	Functions           []function `json:"functions"`
+	CriticalPath        []string   `json:"criticalPath"`
+}
+
+type function struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Module   string `json:"module,omitempty"`
+	IsRoot   bool   `json:"isRoot"`
+
+	// Order is the position in the order in which DI Functions are to be called, or -1 if
+	// DI Functions have not been sorted.
+	Order int `json:"order"`
+
+	// Status is one of "pending", "called", "disabled", "inactive", "overridden" and "failed".
+	Status string `json:"status"`
+
+	Arguments      []value `json:"arguments"`
+	Results        []value `json:"results"`
+	Hooks          []value `json:"hooks"`
+	HasCleanup     bool    `json:"hasCleanup"`
+	CleanupPending bool    `json:"cleanupPending"`
+
+	BodyDuration string `json:"bodyDuration,omitempty"`
+	Duration     string `json:"duration,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type value struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Location   string `json:"location"`
+	IsOptional bool   `json:"isOptional,omitempty"`
+	IsPrivate  bool   `json:"isPrivate,omitempty"`
+
+	// Producer is the name of the DI Function producing the value, for arguments and hooks.
+	Producer string `json:"producer,omitempty"`
+}
+
+func newSnapshot(program *di.Program) *snapshot {
+	graph := program.Graph()
+	state := program.State()
+	report := program.RunReport()
+	var snapshot snapshot
+	snapshot.IsRunning = state.IsRunning
+	snapshot.CalledFunctionCount = state.CalledFunctionCount
+	snapshot.TotalDuration = report.TotalDuration.String()
+	if state.RunErr != nil {
+		snapshot.RunError = state.RunErr.Error()
+	}
+	producerName := func(producerIndex int) string {
+		if producerIndex < 0 {
+			return ""
+		}
+		return graph.Functions[producerIndex].Name
+	}
+	functions := make([]function, len(graph.Functions))
+	for functionIndex := range graph.Functions {
+		functionInfo := &graph.Functions[functionIndex]
+		function := &functions[functionIndex]
+		function.Name = functionInfo.Name
+		function.Location = functionInfo.Location.String()
+		function.Module = functionInfo.ModuleName
+		function.IsRoot = functionInfo.IsRoot
+		function.Order = -1
+		function.Status = "pending"
+		if functionInfo.IsOverridden {
+			function.Status = "overridden"
+		} else if !functionInfo.IsActive {
+			function.Status = "inactive"
+		}
+		for _, argumentInfo := range functionInfo.Arguments {
+			function.Arguments = append(function.Arguments, value{
+				Name:       argumentInfo.ValueRef,
+				Type:       argumentInfo.ValueReceiverType.String(),
+				Location:   argumentInfo.Location.String(),
+				IsOptional: argumentInfo.IsOptional,
+				IsPrivate:  argumentInfo.IsPrivate,
+				Producer:   producerName(argumentInfo.ProducerIndex),
+			})
+		}
+		for _, resultInfo := range functionInfo.Results {
+			function.Results = append(function.Results, value{
+				Name:      resultInfo.ValueName,
+				Type:      resultInfo.ValueType.String(),
+				Location:  resultInfo.Location.String(),
+				IsPrivate: resultInfo.IsPrivate,
+			})
+		}
+		for _, hookInfo := range functionInfo.Hooks {
+			function.Hooks = append(function.Hooks, value{
+				Name:      hookInfo.ValueRef,
+				Type:      hookInfo.ValueReceiverType.String(),
+				Location:  hookInfo.Location.String(),
+				IsPrivate: hookInfo.IsPrivate,
+				Producer:  producerName(hookInfo.ProducerIndex),
+			})
+		}
+		function.HasCleanup = functionInfo.HasCleanup
+	}
+	for order, functionIndex := range state.SortedFunctionIndexes {
+		function := &functions[functionIndex]
+		function.Order = order
+		if order < state.CalledFunctionCount {
+			function.Status = "called"
+		}
+		// Timings of DI Functions are reported in the order in which they were called.
+		if order < len(report.Functions) {
+			functionReport := &report.Functions[order]
+			function.BodyDuration = functionReport.BodyDuration.String()
+			function.Duration = functionReport.Duration.String()
+			if functionReport.Err != nil {
+				function.Status = "failed"
+				function.Error = functionReport.Err.Error()
+			}
+		}
+	}
+	for _, functionIndex := range state.DisabledFunctionIndexes {
+		functions[functionIndex].Status = "disabled"
+	}
+	for _, functionIndex := range state.PendingCleanupIndexes {
+		functions[functionIndex].CleanupPending = true
+	}
+	// List DI Functions in the order in which they are to be called, if sorted.
+	snapshot.Functions = make([]function, 0, len(functions))
+	for _, functionIndex := range state.SortedFunctionIndexes {
+		snapshot.Functions = append(snapshot.Functions, functions[functionIndex])
+	}
+	for _, function := range functions {
+		if function.Order < 0 {
+			snapshot.Functions = append(snapshot.Functions, function)
+		}
+	}
+	for _, functionRef := range report.CriticalPath {
+		snapshot.CriticalPath = append(snapshot.CriticalPath, functionRef.Name)
+	}
+	return &snapshot
+}
+
+func serveJSON(w http.ResponseWriter, snapshot *snapshot) {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
+
+func serveDOT(w http.ResponseWriter, program *di.Program) {
+	var buffer bytes.Buffer
+	if err := program.Graph().WriteDOT(&buffer); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+	w.Write(buffer.Bytes())
+}
+
+func serveHTML(w http.ResponseWriter, snapshot *snapshot) {
+	var buffer bytes.Buffer
+	if err := htmlTemplate.Execute(&buffer, snapshot); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buffer.Bytes())
+}
+
+var htmlTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>/debug/di</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.failed { color: #c00; }
+.pending, .disabled, .inactive, .overridden { color: #888; }
+</style>
+</head>
+<body>
+<h1>/debug/di</h1>
+<p>
+{{if .IsRunning}}running{{else}}not running{{end}},
+{{.CalledFunctionCount}} of {{len .Functions}} DI Functions called in {{.TotalDuration}}
+{{if .CriticalPath}}<br>critical path: {{range $i, $name := .CriticalPath}}{{if $i}} =&gt; {{end}}{{$name}}{{end}}{{end}}
+{{if .RunError}}<br><span class="failed">error: {{.RunError}}</span>{{end}}
+</p>
+<p>View as <a href="?format=json">JSON</a> or <a href="?format=dot">DOT</a>.</p>
+<table>
+<tr><th>#</th><th>Function</th><th>Arguments</th><th>Results</th><th>Hooks</th><th>Status</th><th>Body</th><th>Total</th><th>Cleanup</th></tr>
+{{range .Functions}}
+<tr class="{{.Status}}">
+<td>{{if ge .Order 0}}{{.Order}}{{end}}</td>
+<td title="{{.Location}}">{{.Name}}{{if .Module}} (module {{.Module}}){{end}}{{if .IsRoot}} (root){{end}}</td>
+<td>{{range .Arguments}}<div title="{{.Location}}">{{.Name}} {{.Type}}{{if .IsOptional}} (optional){{end}}{{if .Producer}} &lt;- {{.Producer}}{{end}}</div>{{end}}</td>
+<td>{{range .Results}}<div title="{{.Location}}">{{.Name}} {{.Type}}{{if .IsPrivate}} (private){{end}}</div>{{end}}</td>
+<td>{{range .Hooks}}<div title="{{.Location}}">{{.Name}} {{.Type}}{{if .Producer}} &lt;- {{.Producer}}{{end}}</div>{{end}}</td>
+<td>{{.Status}}{{if .Error}}: {{.Error}}{{end}}</td>
+<td>{{.BodyDuration}}</td>
+<td>{{.Duration}}</td>
+<td>{{if .CleanupPending}}pending{{else if .HasCleanup}}yes{{end}}</td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,114 @@
+package debug_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-tk/di"
+	. "github.com/go-tk/di/debug"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProgram() *di.Program {
+	var program di.Program
+	var x int
+	program.MustNewFunction(di.Result("X", &x), di.Body(func(context.Context) error { return nil }), di.Cleanup(func() {}))
+	program.MustNewFunction(di.Argument("X", &x), di.Body(func(context.Context) error { return errors.New("foo") }))
+	return &program
+}
+
+func serve(handler http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	program := newProgram()
+	handler := Handler(program)
+	_ = program.Run(context.Background())
+
+	w := serve(handler, "/debug/di?format=json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	var snapshot struct {
+		IsRunning           bool
+		CalledFunctionCount int
+		RunError            string
+		Functions           []struct {
+			Name           string
+			Order          int
+			Status         string
+			CleanupPending bool
+			Arguments      []struct{ Name, Type, Producer string }
+			Error          string
+		}
+	}
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot)) {
+		assert.False(t, snapshot.IsRunning)
+		assert.Equal(t, 1, snapshot.CalledFunctionCount)
+		assert.Contains(t, snapshot.RunError, "foo")
+		if assert.Len(t, snapshot.Functions, 2) {
+			f1, f2 := snapshot.Functions[0], snapshot.Functions[1]
+			assert.Equal(t, 0, f1.Order)
+			assert.Equal(t, "called", f1.Status)
+			assert.True(t, f1.CleanupPending)
+			assert.Equal(t, 1, f2.Order)
+			assert.Equal(t, "failed", f2.Status)
+			assert.Equal(t, "foo", f2.Error)
+			if assert.Len(t, f2.Arguments, 1) {
+				assert.Equal(t, "X", f2.Arguments[0].Name)
+				assert.Equal(t, "int", f2.Arguments[0].Type)
+				assert.Equal(t, f1.Name, f2.Arguments[0].Producer)
+			}
+		}
+	}
+
+	w = serve(handler, "/debug/di")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "1 of 2 DI Functions called")
+	assert.Contains(t, w.Body.String(), `<td>failed: foo</td>`)
+
+	w = serve(handler, "/debug/di?format=dot")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "digraph"))
+
+	w = serve(handler, "/debug/di?format=xml")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	program.Clean()
+	w = serve(handler, "/debug/di?format=json")
+	assert.NotContains(t, w.Body.String(), `"cleanupPending": true`)
+}
+
+func TestHandler_Concurrency(t *testing.T) {
+	program := newProgram()
+	handler := Handler(program)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, format := range []string{"html", "json", "dot"} {
+				serve(handler, "/debug/di?format="+format)
+			}
+		}
+	}()
+	_ = program.Run(context.Background())
+	program.Clean()
+	close(done)
+	wg.Wait()
+}
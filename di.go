@@ -6,17 +6,42 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Program consists of DI Functions which are containers for dependency injection.
 type Program struct {
+	// RunOptions controls how the Program is validated and run.
+	RunOptions RunOptions
+
 	functions             []function
 	arguments             []argument
 	results               []result
 	hooks                 []hook
+	parent                *Program
+	moduleNames           []string
+	currentModuleName     string
+	isResolved            bool
+	resolveErr            error
+	activeProfiles        []string
 	sortedFunctionIndexes []int
-	calledFunctionCount   int
+	now                   func() time.Time
+
+	// mutex guards states below, which are updated during Program.Run()/Program.Clean(),
+	// and the resolution of DI Functions.
+	mutex               sync.Mutex
+	calledFunctionCount int
+	functionCalls       []functionCall
+	disabledFunctions   map[int]*condition
+	cleanedCallCount    int
+	isRunning           bool
+	runStartTime        time.Time
+	runEndTime          time.Time
+	runErr              error
+	functionTimings     []functionTiming
 }
 
 type function struct {
@@ -27,6 +52,44 @@ type function struct {
 	Body            func(context.Context) error
 	HookIndexes     []int
 	Cleanup         func()
+	IsRoot          bool
+	Location        Location
+	ModuleName      string
+	IsTransient     bool
+	Conditions      []condition
+	Profiles        []string
+
+	// IsOverridden indicates whether the DI Function is replaced by another one, and
+	// OverriddenIndexes are indexes of DI Functions replaced by the DI Function, see
+	// Program.Override().
+	IsOverridden      bool
+	OverriddenIndexes []int
+}
+
+// RunOptions represents options for Program.Validate()/Program.Run().
+type RunOptions struct {
+	// StrictMode makes Program.Validate()/Program.Run() fail when unused results or dead
+	// DI Functions are detected, see Program.Analyze() for details.
+	StrictMode bool
+
+	// Observer, if set, is notified of lifecycle events of Program.Run()/Program.Clean().
+	Observer Observer
+
+	// Tracer, if set, starts spans for Program.Run()/Program.Clean(), see Tracer for details.
+	Tracer Tracer
+
+	// PprofLabels makes Program.Run()/Program.Clean() call bodies, hook callbacks and cleanups
+	// of DI Functions with pprof labels, so that profiles can be filtered per DI Function.
+	// Keys of labels are PprofLabelFunction, PprofLabelPhase and PprofLabelValue.
+	PprofLabels bool
+
+	// Progress, if set, is called as Program.Run() advances: before the body and each hook
+	// callback of a DI Function is called, and finally once all DI Functions have been called.
+	Progress func(progress Progress)
+
+	// ActiveProfiles are profiles to activate, DI Functions restricted to other profiles are
+	// excluded from the Program, see Profiles().
+	ActiveProfiles []string
 }
 
 // FunctionBuilder is the type of function that constructs a DI Function.
@@ -34,44 +97,87 @@ type FunctionBuilder func(function *function, program *Program) (err error)
 
 // NewFunction add a DI Function into the Program.
 func (p *Program) NewFunction(functionBuilders ...FunctionBuilder) error {
-	pc, _, _, _ := runtime.Caller(1)
+	pc, file, line, _ := runtime.Caller(1)
 	functionName := runtime.FuncForPC(pc).Name()
-	return p.doNewFunction(functionName, functionBuilders...)
+	return p.doNewFunction(functionName, Location{file, line}, functionBuilders...)
 }
 
 // MustNewFunction likes NewFunction but panics when an error occurs.
 func (p *Program) MustNewFunction(functionBuilders ...FunctionBuilder) {
-	pc, _, _, _ := runtime.Caller(1)
+	pc, file, line, _ := runtime.Caller(1)
 	functionName := runtime.FuncForPC(pc).Name()
-	if err := p.doNewFunction(functionName, functionBuilders...); err != nil {
+	if err := p.doNewFunction(functionName, Location{file, line}, functionBuilders...); err != nil {
 		panic(fmt.Sprintf("new function: %v", err))
 	}
 }
 
-func (p *Program) doNewFunction(functionName string, functionBuilders ...FunctionBuilder) (returnedErr error) {
+func (p *Program) doNewFunction(functionName string, location Location, functionBuilders ...FunctionBuilder) (returnedErr error) {
+	p.isResolved = false
 	functionIndex := len(p.functions)
+	argumentCount, resultCount, hookCount := len(p.arguments), len(p.results), len(p.hooks)
 	p.functions = append(p.functions, function{Index: functionIndex})
 	defer func() {
 		if returnedErr != nil {
 			p.functions = p.functions[:functionIndex]
+			p.arguments = p.arguments[:argumentCount]
+			p.results = p.results[:resultCount]
+			p.hooks = p.hooks[:hookCount]
 		}
 	}()
 	function := &p.functions[functionIndex]
 	function.Name = functionName
+	function.Location = location
+	function.ModuleName = p.currentModuleName
 	for _, functionBuilder := range functionBuilders {
 		if err := functionBuilder(function, p); err != nil {
 			return err
 		}
 	}
 	if function.Body == nil {
-		return fmt.Errorf("%w; functionName=%q", ErrBodyRequired, functionName)
+		return fmt.Errorf("%w; functionName=%q location=%q", ErrBodyRequired, functionName, location)
+	}
+	if function.IsTransient {
+		if len(function.ResultIndexes) == 0 {
+			return fmt.Errorf("%w: no result; functionName=%q location=%q", ErrInvalidTransient, functionName, location)
+		}
+		if len(function.HookIndexes) >= 1 {
+			return fmt.Errorf("%w: hook specified; functionName=%q location=%q", ErrInvalidTransient, functionName, location)
+		}
+		if len(function.Conditions) >= 1 {
+			return fmt.Errorf("%w: condition specified; functionName=%q location=%q", ErrInvalidTransient, functionName, location)
+		}
 	}
 	return nil
 }
 
+// isRoot checks whether the DI Function is a root, i.e. it is marked by Root() or has no result.
+func (f *function) isRoot() bool {
+	return f.IsRoot || len(f.ResultIndexes) == 0
+}
+
+// Location represents a location in source code.
+type Location struct {
+	File string
+	Line int
+}
+
+// String returns the location in the form of "file:line".
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+func callerLocation(skip int) Location {
+	_, file, line, _ := runtime.Caller(skip + 1)
+	return Location{file, line}
+}
+
 // ErrBodyRequired is returned by Program.NewFunction() when no body is specified.
 var ErrBodyRequired = errors.New("di: body required")
 
+// ErrInvalidTransient is returned by Program.NewFunction() when a transient DI Function has no
+// result, or has hooks or conditions.
+var ErrInvalidTransient = errors.New("di: invalid transient")
+
 type argument struct {
 	FunctionIndex    int
 	ValueRef         string
@@ -79,33 +185,36 @@ type argument struct {
 	IsOptional       bool
 	ResultIndex      int
 	ReceiveValueAddr bool
+	Location         Location
+	InheritedValue   reflect.Value
+	IsCondition      bool
 }
 
 // Argument specifies an argument for a DI Function.
 func Argument(valueRef string, rawValueReceiverPtr interface{}) FunctionBuilder {
-	return argument1(valueRef, rawValueReceiverPtr, false)
+	return argument1(valueRef, rawValueReceiverPtr, false, callerLocation(1))
 }
 
 // OptionalArgument specifies an optional argument for a DI Function.
 func OptionalArgument(valueRef string, rawValueReceiverPtr interface{}) FunctionBuilder {
-	return argument1(valueRef, rawValueReceiverPtr, true)
+	return argument1(valueRef, rawValueReceiverPtr, true, callerLocation(1))
 }
 
-func argument1(valueRef string, rawValueReceiverPtr interface{}, isOptional bool) FunctionBuilder {
+func argument1(valueRef string, rawValueReceiverPtr interface{}, isOptional bool, location Location) FunctionBuilder {
 	return func(function *function, program *Program) error {
 		if valueRef == "" {
-			return fmt.Errorf("%w: empty value ref; functionName=%q", ErrInvalidArgument, function.Name)
+			return fmt.Errorf("%w: empty value ref; functionName=%q location=%q", ErrInvalidArgument, function.Name, location)
 		}
 		if rawValueReceiverPtr == nil {
-			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q", ErrInvalidArgument, function.Name, valueRef)
+			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q location=%q", ErrInvalidArgument, function.Name, valueRef, location)
 		}
 		valueReceiverPtr := reflect.ValueOf(rawValueReceiverPtr)
 		if valueReceiverPtr.Kind() != reflect.Ptr {
-			return fmt.Errorf("%w: invalid value receiver pointer; valueReceiverPtrType=%q functionName=%q valueRef=%q",
-				ErrInvalidArgument, valueReceiverPtr.Type(), function.Name, valueRef)
+			return fmt.Errorf("%w: invalid value receiver pointer; valueReceiverPtrType=%q functionName=%q valueRef=%q location=%q",
+				ErrInvalidArgument, valueReceiverPtr.Type(), function.Name, valueRef, location)
 		}
 		if valueReceiverPtr.IsNil() {
-			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q", ErrInvalidArgument, function.Name, valueRef)
+			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q location=%q", ErrInvalidArgument, function.Name, valueRef, location)
 		}
 		argumentIndex := len(program.arguments)
 		program.arguments = append(program.arguments, argument{})
@@ -115,6 +224,7 @@ func argument1(valueRef string, rawValueReceiverPtr interface{}, isOptional bool
 		argument.ValueReceiver = valueReceiverPtr.Elem()
 		argument.IsOptional = isOptional
 		argument.ResultIndex = -1
+		argument.Location = location
 		function.ArgumentIndexes = append(function.ArgumentIndexes, argumentIndex)
 		return nil
 	}
@@ -128,31 +238,49 @@ type result struct {
 	ValueName     string
 	Value         reflect.Value
 	HookIndexes   []int
+	Location      Location
+	IsPrivate     bool
 }
 
 // Result specifies a result for a DI Function.
 func Result(valueName string, rawValuePtr interface{}) FunctionBuilder {
+	return result1(valueName, rawValuePtr, false, callerLocation(1))
+}
+
+// PrivateResult specifies a private result for a DI Function in a Module, see Program.Install().
+// A private value can only be referenced by arguments and hooks of DI Functions in the same Module.
+func PrivateResult(valueName string, rawValuePtr interface{}) FunctionBuilder {
+	return result1(valueName, rawValuePtr, true, callerLocation(1))
+}
+
+func result1(valueName string, rawValuePtr interface{}, isPrivate bool, location Location) FunctionBuilder {
 	return func(function *function, program *Program) error {
 		if valueName == "" {
-			return fmt.Errorf("%w: empty value name; functionName=%q", ErrInvalidResult, function.Name)
+			return fmt.Errorf("%w: empty value name; functionName=%q location=%q", ErrInvalidResult, function.Name, location)
 		}
 		if rawValuePtr == nil {
-			return fmt.Errorf("%w: no value; functionName=%q valueName=%q", ErrInvalidResult, function.Name, valueName)
+			return fmt.Errorf("%w: no value; functionName=%q valueName=%q location=%q", ErrInvalidResult, function.Name, valueName, location)
 		}
 		valuePtr := reflect.ValueOf(rawValuePtr)
 		if valuePtr.Kind() != reflect.Ptr {
-			return fmt.Errorf("%w: invalid value pointer; valuePtrType=%q functionName=%q valueName=%q",
-				ErrInvalidResult, valuePtr.Type(), function.Name, valueName)
+			return fmt.Errorf("%w: invalid value pointer; valuePtrType=%q functionName=%q valueName=%q location=%q",
+				ErrInvalidResult, valuePtr.Type(), function.Name, valueName, location)
 		}
 		if valuePtr.IsNil() {
-			return fmt.Errorf("%w: no value; functionName=%q valueName=%q", ErrInvalidResult, function.Name, valueName)
+			return fmt.Errorf("%w: no value; functionName=%q valueName=%q location=%q", ErrInvalidResult, function.Name, valueName, location)
+		}
+		if isPrivate && function.ModuleName == "" {
+			return fmt.Errorf("%w: private result outside module; functionName=%q valueName=%q location=%q",
+				ErrInvalidResult, function.Name, valueName, location)
 		}
 		resultIndex := len(program.results)
 		program.results = append(program.results, result{})
 		result := &program.results[resultIndex]
 		result.FunctionIndex = function.Index
-		result.ValueName = valueName
+		result.ValueName = qualifyValueName(function.ModuleName, valueName)
 		result.Value = valuePtr.Elem()
+		result.Location = location
+		result.IsPrivate = isPrivate
 		function.ResultIndexes = append(function.ResultIndexes, resultIndex)
 		return nil
 	}
@@ -165,7 +293,7 @@ var ErrInvalidResult = errors.New("di: invalid result")
 func Body(body func(context.Context) error) FunctionBuilder {
 	return func(function *function, program *Program) error {
 		if body == nil {
-			return fmt.Errorf("%w; functionName=%q", ErrNilBody, function.Name)
+			return fmt.Errorf("%w; functionName=%q location=%q", ErrNilBody, function.Name, function.Location)
 		}
 		function.Body = body
 		return nil
@@ -179,7 +307,7 @@ var ErrNilBody = errors.New("di: nil body")
 func Cleanup(cleanup func()) FunctionBuilder {
 	return func(function *function, program *Program) error {
 		if cleanup == nil {
-			return fmt.Errorf("%w; functionName=%q", ErrNilCleanup, function.Name)
+			return fmt.Errorf("%w; functionName=%q location=%q", ErrNilCleanup, function.Name, function.Location)
 		}
 		function.Cleanup = cleanup
 		return nil
@@ -189,33 +317,46 @@ func Cleanup(cleanup func()) FunctionBuilder {
 // ErrNilCleanup is returned by Program.NewFunction() when nil cleanup is specified.
 var ErrNilCleanup = errors.New("di: nil cleanup")
 
+// Root marks a DI Function as a root, which is called for its side effects, so that the
+// DI Function is never regarded as dead even if its results are unused.
+func Root() FunctionBuilder {
+	return func(function *function, program *Program) error {
+		function.IsRoot = true
+		return nil
+	}
+}
+
 type hook struct {
-	FunctionIndex    int
-	ValueRef         string
-	ValueReceiver    reflect.Value
-	Callback         func(context.Context) error
-	ReceiveValueAddr bool
+	FunctionIndex         int
+	ValueRef              string
+	ValueReceiver         reflect.Value
+	Callback              func(context.Context) error
+	ReceiveValueAddr      bool
+	Location              Location
+	InheritedValue        reflect.Value
+	InheritedProducerName string
 }
 
 // Hook specifies a hook for a DI Function.
 func Hook(valueRef string, rawValueReceiverPtr interface{}, callback func(context.Context) error) FunctionBuilder {
+	location := callerLocation(1)
 	return func(function *function, program *Program) error {
 		if valueRef == "" {
-			return fmt.Errorf("%w: empty value ref; functionName=%q", ErrInvalidHook, function.Name)
+			return fmt.Errorf("%w: empty value ref; functionName=%q location=%q", ErrInvalidHook, function.Name, location)
 		}
 		if rawValueReceiverPtr == nil {
-			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q", ErrInvalidHook, function.Name, valueRef)
+			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q location=%q", ErrInvalidHook, function.Name, valueRef, location)
 		}
 		valueReceiverPtr := reflect.ValueOf(rawValueReceiverPtr)
 		if valueReceiverPtr.Kind() != reflect.Ptr {
-			return fmt.Errorf("%w: invalid value receiver pointer; valueReceiverPtrType=%q functionName=%q valueRef=%q",
-				ErrInvalidHook, valueReceiverPtr.Type(), function.Name, valueRef)
+			return fmt.Errorf("%w: invalid value receiver pointer; valueReceiverPtrType=%q functionName=%q valueRef=%q location=%q",
+				ErrInvalidHook, valueReceiverPtr.Type(), function.Name, valueRef, location)
 		}
 		if valueReceiverPtr.IsNil() {
-			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q", ErrInvalidHook, function.Name, valueRef)
+			return fmt.Errorf("%w: no value receiver; functionName=%q valueRef=%q location=%q", ErrInvalidHook, function.Name, valueRef, location)
 		}
 		if callback == nil {
-			return fmt.Errorf("%w: nil callback; functionName=%q valueRef=%q", ErrInvalidHook, function.Name, valueRef)
+			return fmt.Errorf("%w: nil callback; functionName=%q valueRef=%q location=%q", ErrInvalidHook, function.Name, valueRef, location)
 		}
 		hookIndex := len(program.hooks)
 		program.hooks = append(program.hooks, hook{})
@@ -224,6 +365,7 @@ func Hook(valueRef string, rawValueReceiverPtr interface{}, callback func(contex
 		hook.ValueRef = valueRef
 		hook.ValueReceiver = valueReceiverPtr.Elem()
 		hook.Callback = callback
+		hook.Location = location
 		function.HookIndexes = append(function.HookIndexes, hookIndex)
 		return nil
 	}
@@ -232,203 +374,861 @@ func Hook(valueRef string, rawValueReceiverPtr interface{}, callback func(contex
 // ErrInvalidHook is returned by Program.NewFunction() when an invalid hook is specified.
 var ErrInvalidHook = errors.New("di: invalid hook")
 
-// Run calls all DI Functions added into the Program, the order in which DI Functions are to be called
-// is based on dependency analysis.
-func (p *Program) Run(ctx context.Context) error {
+// Validate checks whether DI Functions added into the Program are wired correctly, without calling them.
+// All problems detected are reported at once as WiringErrors. Unlike Program.Run(), it also checks
+// each profile, activated alone, for missing values, duplicate value names and incompatible value
+// receivers, see Profiles().
+func (p *Program) Validate() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.validate(); err != nil {
+		return err
+	}
+	return p.checkProfiles()
+}
+
+// validate validates DI Functions under the active profiles.
+func (p *Program) validate() error {
+	if p.parent != nil {
+		// Values inherited depend on the progress of ancestors.
+		p.isResolved = false
+	}
 	if err := p.resolve(); err != nil {
 		return err
 	}
 	if err := p.sortFunctions(); err != nil {
 		return err
 	}
+	if p.RunOptions.StrictMode {
+		if err := p.analyze().err(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run calls all DI Functions added into the Program, the order in which DI Functions are to be called
+// is based on dependency analysis.
+func (p *Program) Run(ctx context.Context) error {
+	return p.run(ctx, func() error {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		return p.validate()
+	})
+}
+
+// run calls DI Functions after preparing them with the given function, which resolves values
+// and sorts DI Functions.
+func (p *Program) run(ctx context.Context, prepare func() error) (returnedErr error) {
+	ctx, span := p.tracer().Start(ctx, "di.run")
+	defer func() { endSpan(span, returnedErr) }()
+	observer := p.observer()
+	runStartTime := p.timeNow()
+	p.mutex.Lock()
+	p.isRunning = true
+	p.runStartTime = runStartTime
+	// Reset states of the previous run, if any. Calls whose cleanups have not been called yet
+	// are kept, so that Program.Clean() still calls them.
+	p.calledFunctionCount = 0
+	p.functionCalls = p.functionCalls[:len(p.functionCalls)-p.cleanedCallCount]
+	p.cleanedCallCount = 0
+	p.disabledFunctions = nil
+	p.functionTimings = nil
+	p.mutex.Unlock()
+	defer func() {
+		runEndTime := p.timeNow()
+		p.mutex.Lock()
+		p.isRunning = false
+		p.runEndTime = runEndTime
+		p.runErr = returnedErr
+		calledFunctionCount := p.calledFunctionCount
+		p.mutex.Unlock()
+		observer.OnRunEnd(ctx, RunEvent{
+			StartTime:           runStartTime,
+			Duration:            runEndTime.Sub(runStartTime),
+			CalledFunctionCount: calledFunctionCount,
+			Err:                 returnedErr,
+		})
+	}()
+	err := prepare()
+	observer.OnResolveDone(ctx, ResolveEvent{
+		StartTime: runStartTime,
+		Duration:  p.timeNow().Sub(runStartTime),
+		Err:       err,
+	})
+	if err != nil {
+		return err
+	}
 	return p.callFunctions(ctx)
 }
 
+// calledFunctionIndexes returns indexes of DI Functions called, in the order in which they were
+// called. The Program may have been sorted again since, e.g. under other profiles, hence the
+// bound check.
+func (p *Program) calledFunctionIndexes() []int {
+	if p.calledFunctionCount > len(p.sortedFunctionIndexes) {
+		return p.sortedFunctionIndexes
+	}
+	return p.sortedFunctionIndexes[:p.calledFunctionCount]
+}
+
+func (p *Program) timeNow() time.Time {
+	if p.now == nil {
+		return time.Now()
+	}
+	return p.now()
+}
+
+// resolve resolves values used by arguments and hooks, the result is cached until more DI
+// Functions are added or active profiles are changed.
 func (p *Program) resolve() error {
+	if !p.isResolved || !equalProfiles(p.activeProfiles, p.RunOptions.ActiveProfiles) {
+		p.activeProfiles = append([]string(nil), p.RunOptions.ActiveProfiles...)
+		p.resolveErr = p.doResolve()
+		p.isResolved = true
+	}
+	return p.resolveErr
+}
+
+func (p *Program) doResolve() error {
+	for argumentIndex := range p.arguments {
+		argument := &p.arguments[argumentIndex]
+		argument.ResultIndex = -1
+		argument.ReceiveValueAddr = false
+		argument.InheritedValue = reflect.Value{}
+	}
+	for resultIndex := range p.results {
+		result := &p.results[resultIndex]
+		result.HookIndexes = nil
+	}
+	for hookIndex := range p.hooks {
+		hook := &p.hooks[hookIndex]
+		hook.ReceiveValueAddr = false
+		hook.InheritedValue = reflect.Value{}
+		hook.InheritedProducerName = ""
+	}
+	var errs WiringErrors
 	valueName2ResultIndex := make(map[string]int, len(p.results))
 	for resultIndex := range p.results {
 		result := &p.results[resultIndex]
+		if !p.isActive(result.FunctionIndex) {
+			continue
+		}
 		if resultIndex2, ok := valueName2ResultIndex[result.ValueName]; ok {
 			result2 := &p.results[resultIndex2]
-			return fmt.Errorf("%w; valueName=%q functionName1=%q functionName2=%q",
-				ErrDuplicateValueName, result.ValueName, p.functions[result.FunctionIndex].Name,
-				p.functions[result2.FunctionIndex].Name)
+			errs = append(errs, &DuplicateValueNameError{
+				ValueName:      result.ValueName,
+				FunctionName1:  p.functions[result.FunctionIndex].Name,
+				FunctionName2:  p.functions[result2.FunctionIndex].Name,
+				Location1:      result.Location,
+				Location2:      result2.Location,
+				ActiveProfiles: p.activeProfiles,
+			})
+			continue
 		}
 		valueName2ResultIndex[result.ValueName] = resultIndex
 	}
 	for argumentIndex := range p.arguments {
 		argument := &p.arguments[argumentIndex]
-		resultIndex, ok := valueName2ResultIndex[argument.ValueRef]
+		if !p.isActive(argument.FunctionIndex) {
+			continue
+		}
+		resultIndex, ok := p.lookUpValue(argument.ValueRef, p.functions[argument.FunctionIndex].ModuleName, valueName2ResultIndex)
 		if !ok {
+			// An inherited value of WhenValue() whose producer is disabled disables the DI Function,
+			// as within a Program.
+			inheritedValue, ok, err := p.resolveInheritedValue(argument.ValueRef, argument.ValueReceiver,
+				argument.IsOptional || argument.IsCondition, argument.FunctionIndex, argument.Location, DependencyArgument)
+			if ok {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				argument.InheritedValue = inheritedValue.Value
+				argument.ReceiveValueAddr = inheritedValue.ReceiveValueAddr
+				continue
+			}
 			if argument.IsOptional {
 				continue
 			}
-			return fmt.Errorf("%w; valueRef=%q functionName=%q",
-				ErrValueNotFound, argument.ValueRef, p.functions[argument.FunctionIndex].Name)
+			errs = append(errs, &ValueNotFoundError{
+				ValueRef:       argument.ValueRef,
+				FunctionName:   p.functions[argument.FunctionIndex].Name,
+				ModuleName:     p.functions[argument.FunctionIndex].ModuleName,
+				Location:       argument.Location,
+				Kind:           DependencyArgument,
+				Suggestions:    p.suggestValueNames(argument.ValueRef, p.functions[argument.FunctionIndex].ModuleName, valueName2ResultIndex),
+				ActiveProfiles: p.activeProfiles,
+			})
+			continue
 		}
 		result := &p.results[resultIndex]
+		if !p.isVisible(resultIndex, p.functions[argument.FunctionIndex].ModuleName) {
+			errs = append(errs, &PrivateValueError{
+				ValueRef:        argument.ValueRef,
+				ValueModuleName: p.functions[result.FunctionIndex].ModuleName,
+				FunctionName:    p.functions[argument.FunctionIndex].Name,
+				ModuleName:      p.functions[argument.FunctionIndex].ModuleName,
+				Location:        argument.Location,
+				Kind:            DependencyArgument,
+			})
+			continue
+		}
 		valueType := result.Value.Type()
 		valueReceiverType := argument.ValueReceiver.Type()
 		if valueReceiverType == reflect.PtrTo(valueType) {
 			argument.ReceiveValueAddr = true
 		} else {
 			if !valueType.AssignableTo(valueReceiverType) {
-				return fmt.Errorf("%w; valueReceiverType=%q valueType=%q valueRef=%q functionName=%q",
-					ErrIncompatibleValueReceiver, valueReceiverType, valueType, argument.ValueRef,
-					p.functions[argument.FunctionIndex].Name)
+				errs = append(errs, &IncompatibleReceiverError{
+					ValueRef:          argument.ValueRef,
+					ValueReceiverType: valueReceiverType,
+					ValueType:         valueType,
+					FunctionName:      p.functions[argument.FunctionIndex].Name,
+					ModuleName:        p.functions[argument.FunctionIndex].ModuleName,
+					Location:          argument.Location,
+					Kind:              DependencyArgument,
+					ActiveProfiles:    p.activeProfiles,
+				})
+				continue
 			}
 		}
 		argument.ResultIndex = resultIndex
 	}
 	for hookIndex := range p.hooks {
 		hook := &p.hooks[hookIndex]
-		resultIndex, ok := valueName2ResultIndex[hook.ValueRef]
+		if !p.isActive(hook.FunctionIndex) {
+			continue
+		}
+		resultIndex, ok := p.lookUpValue(hook.ValueRef, p.functions[hook.FunctionIndex].ModuleName, valueName2ResultIndex)
 		if !ok {
-			return fmt.Errorf("%w; valueRef=%q functionName=%q",
-				ErrValueNotFound, hook.ValueRef, p.functions[hook.FunctionIndex].Name)
+			// Hooks on values of disabled producers are skipped, as within a Program.
+			inheritedValue, ok, err := p.resolveInheritedValue(hook.ValueRef, hook.ValueReceiver, true,
+				hook.FunctionIndex, hook.Location, DependencyHook)
+			if ok {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				hook.InheritedValue = inheritedValue.Value
+				hook.ReceiveValueAddr = inheritedValue.ReceiveValueAddr
+				hook.InheritedProducerName = inheritedValue.ProducerName
+				continue
+			}
+			errs = append(errs, &ValueNotFoundError{
+				ValueRef:       hook.ValueRef,
+				FunctionName:   p.functions[hook.FunctionIndex].Name,
+				ModuleName:     p.functions[hook.FunctionIndex].ModuleName,
+				Location:       hook.Location,
+				Kind:           DependencyHook,
+				Suggestions:    p.suggestValueNames(hook.ValueRef, p.functions[hook.FunctionIndex].ModuleName, valueName2ResultIndex),
+				ActiveProfiles: p.activeProfiles,
+			})
+			continue
 		}
 		result := &p.results[resultIndex]
+		if !p.isVisible(resultIndex, p.functions[hook.FunctionIndex].ModuleName) {
+			errs = append(errs, &PrivateValueError{
+				ValueRef:        hook.ValueRef,
+				ValueModuleName: p.functions[result.FunctionIndex].ModuleName,
+				FunctionName:    p.functions[hook.FunctionIndex].Name,
+				ModuleName:      p.functions[hook.FunctionIndex].ModuleName,
+				Location:        hook.Location,
+				Kind:            DependencyHook,
+			})
+			continue
+		}
+		if producer := &p.functions[result.FunctionIndex]; producer.IsTransient {
+			errs = append(errs, &TransientValueHookedError{
+				ValueRef:     hook.ValueRef,
+				ProducerName: producer.Name,
+				FunctionName: p.functions[hook.FunctionIndex].Name,
+				ModuleName:   p.functions[hook.FunctionIndex].ModuleName,
+				Location:     hook.Location,
+			})
+			continue
+		}
 		valueType := result.Value.Type()
 		valueReceiverType := hook.ValueReceiver.Type()
 		if valueReceiverType == reflect.PtrTo(valueType) {
 			hook.ReceiveValueAddr = true
 		} else {
 			if !valueType.AssignableTo(valueReceiverType) {
-				return fmt.Errorf("%w; valueReceiverType=%q valueType=%q valueRef=%q functionName=%q",
-					ErrIncompatibleValueReceiver, valueReceiverType, valueType, hook.ValueRef,
-					p.functions[hook.FunctionIndex].Name)
+				errs = append(errs, &IncompatibleReceiverError{
+					ValueRef:          hook.ValueRef,
+					ValueReceiverType: valueReceiverType,
+					ValueType:         valueType,
+					FunctionName:      p.functions[hook.FunctionIndex].Name,
+					ModuleName:        p.functions[hook.FunctionIndex].ModuleName,
+					Location:          hook.Location,
+					Kind:              DependencyHook,
+					ActiveProfiles:    p.activeProfiles,
+				})
+				continue
 			}
 		}
 		result.HookIndexes = append(result.HookIndexes, hookIndex)
 	}
+	if len(errs) >= 1 {
+		errs.sort()
+		return errs
+	}
 	return nil
 }
 
 func (p *Program) sortFunctions() error {
-	var walk func(*function, interface{}) bool
-	var path []interface{}
-	visitedFunctionIndexes := make(map[int]struct{}, len(p.functions))
-	walk = func(function *function, from interface{}) bool {
-		functionIndex := function.Index
-		if _, ok := visitedFunctionIndexes[functionIndex]; ok {
-			return true
+	p.sortedFunctionIndexes = p.sortedFunctionIndexes[:0]
+	var errs WiringErrors
+	for _, component := range p.findStronglyConnectedComponents() {
+		if len(component) == 1 && !p.dependsOn(component[0], component[0]) {
+			if p.isActive(component[0]) {
+				p.sortedFunctionIndexes = append(p.sortedFunctionIndexes, component[0])
+			}
+			continue
 		}
-		pathLength := len(path)
-		if from == nil {
-			path = append(path, function)
-		} else {
-			path = append(path, from, function)
+		errs = append(errs, &CircularDependencyError{Cycle: p.findCycle(component)})
+	}
+	if len(errs) >= 1 {
+		p.sortedFunctionIndexes = p.sortedFunctionIndexes[:0]
+		errs.sort()
+		return errs
+	}
+	return nil
+}
+
+// FindCycles returns all dependency cycles among DI Functions added into the Program, one cycle
+// for each group of DI Functions depending on each other. Values which cannot be resolved are
+// ignored.
+func (p *Program) FindCycles() []DependencyCycle {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_ = p.resolve()
+	var cycles []DependencyCycle
+	for _, component := range p.findStronglyConnectedComponents() {
+		if len(component) == 1 && !p.dependsOn(component[0], component[0]) {
+			continue
 		}
-		if functionIndex < 0 {
-			return false
+		cycles = append(cycles, p.findCycle(component))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].String() < cycles[j].String() })
+	return cycles
+}
+
+// DependencyKind indicates how a DI Function depends on another DI Function.
+type DependencyKind string
+
+const (
+	// DependencyArgument indicates the DI Function has an argument referencing a value
+	// which is a result of another DI Function.
+	DependencyArgument = DependencyKind("argument")
+
+	// DependencyHook indicates the DI Function has a result referenced by a hook of
+	// another DI Function.
+	DependencyHook = DependencyKind("hook")
+)
+
+// DependencyStep is a step of a dependency path.
+type DependencyStep struct {
+	FunctionName string
+	Kind         DependencyKind
+	ValueRef     string
+
+	// Location is the location of the argument or the hook.
+	Location Location
+}
+
+// DependencyCycle is a dependency path in which each DI Function depends on the DI Function
+// of the next step, and the DI Function of the last step depends on the DI Function of the
+// first step.
+type DependencyCycle []DependencyStep
+
+// String returns the dependency path in the form of "A@argument:X => B@hook:Y => A".
+func (c DependencyCycle) String() string {
+	if len(c) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	for _, step := range c {
+		builder.WriteString(fmt.Sprintf("%s@%s:%s => ", step.FunctionName, step.Kind, step.ValueRef))
+	}
+	builder.WriteString(c[0].FunctionName)
+	return builder.String()
+}
+
+func (c DependencyCycle) locations() string {
+	locations := make([]string, len(c))
+	for i, step := range c {
+		locations[i] = step.Location.String()
+	}
+	return strings.Join(locations, ", ")
+}
+
+type dependency struct {
+	FunctionIndex int
+	Kind          DependencyKind
+	ValueRef      string
+	Location      Location
+}
+
+// dependencies returns DI Functions which have to be called before the given DI Function.
+func (p *Program) dependencies(function *function) []dependency {
+	var dependencies []dependency
+	for _, argumentIndex := range function.ArgumentIndexes {
+		argument := &p.arguments[argumentIndex]
+		if argument.ResultIndex < 0 {
+			continue
 		}
-		function.Index = -1
-		for _, argumentIndex := range function.ArgumentIndexes {
-			argument := &p.arguments[argumentIndex]
-			if argument.ResultIndex < 0 {
-				continue
-			}
-			result := &p.results[argument.ResultIndex]
-			function2 := &p.functions[result.FunctionIndex]
-			if !walk(function2, argument) {
-				return false
-			}
+		result := &p.results[argument.ResultIndex]
+		dependencies = append(dependencies, dependency{result.FunctionIndex, DependencyArgument, argument.ValueRef, argument.Location})
+	}
+	for _, resultIndex := range function.ResultIndexes {
+		result := &p.results[resultIndex]
+		for _, hookIndex := range result.HookIndexes {
+			hook := &p.hooks[hookIndex]
+			dependencies = append(dependencies, dependency{hook.FunctionIndex, DependencyHook, hook.ValueRef, hook.Location})
 		}
-		for _, resultIndex := range function.ResultIndexes {
-			result := &p.results[resultIndex]
-			for _, hookIndex := range result.HookIndexes {
-				hook := &p.hooks[hookIndex]
-				function2 := &p.functions[hook.FunctionIndex]
-				if !walk(function2, hook) {
-					return false
+	}
+	return dependencies
+}
+
+func (p *Program) dependsOn(functionIndex1 int, functionIndex2 int) bool {
+	for _, dependency := range p.dependencies(&p.functions[functionIndex1]) {
+		if dependency.FunctionIndex == functionIndex2 {
+			return true
+		}
+	}
+	return false
+}
+
+// findStronglyConnectedComponents finds strongly connected components with Tarjan's algorithm,
+// components are returned in such an order that each component comes after the components it
+// depends on.
+func (p *Program) findStronglyConnectedComponents() [][]int {
+	var (
+		nextOrder  int
+		orders     = make([]int, len(p.functions))
+		lowLinks   = make([]int, len(p.functions))
+		isOnStack  = make([]bool, len(p.functions))
+		stack      []int
+		components [][]int
+	)
+	var visit func(int)
+	visit = func(functionIndex int) {
+		nextOrder++
+		orders[functionIndex] = nextOrder
+		lowLinks[functionIndex] = nextOrder
+		stack = append(stack, functionIndex)
+		isOnStack[functionIndex] = true
+		for _, dependency := range p.dependencies(&p.functions[functionIndex]) {
+			functionIndex2 := dependency.FunctionIndex
+			if orders[functionIndex2] == 0 {
+				visit(functionIndex2)
+				if lowLinks[functionIndex2] < lowLinks[functionIndex] {
+					lowLinks[functionIndex] = lowLinks[functionIndex2]
+				}
+			} else if isOnStack[functionIndex2] {
+				if orders[functionIndex2] < lowLinks[functionIndex] {
+					lowLinks[functionIndex] = orders[functionIndex2]
 				}
 			}
 		}
-		function.Index = functionIndex
-		path = path[:pathLength]
-		visitedFunctionIndexes[functionIndex] = struct{}{}
-		p.sortedFunctionIndexes = append(p.sortedFunctionIndexes, functionIndex)
-		return true
-	}
-	dumpPath := func() string {
-		var builder strings.Builder
-		n := len(path)
-		for i, j := 0, n-1; i < j; i += 2 {
-			function, from := path[i].(*function), path[i+1]
-			switch from := from.(type) {
-			case *argument:
-				builder.WriteString(fmt.Sprintf("%s@argument:%s => ", function.Name, from.ValueRef))
-			case *hook:
-				builder.WriteString(fmt.Sprintf("%s@hook:%s => ", function.Name, from.ValueRef))
-			default:
-				panic("unreachable code")
+		if lowLinks[functionIndex] != orders[functionIndex] {
+			return
+		}
+		var component []int
+		for {
+			functionIndex2 := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			isOnStack[functionIndex2] = false
+			component = append(component, functionIndex2)
+			if functionIndex2 == functionIndex {
+				break
 			}
 		}
-		function := path[n-1].(*function)
-		builder.WriteString(function.Name)
-		return builder.String()
+		components = append(components, component)
 	}
 	for functionIndex := range p.functions {
-		function := &p.functions[functionIndex]
-		if !walk(function, nil) {
-			return fmt.Errorf("%w; path=%q", ErrCircularDependencies, dumpPath())
+		if orders[functionIndex] == 0 {
+			visit(functionIndex)
 		}
 	}
-	return nil
+	return components
+}
+
+// findCycle finds the shortest cycle in the given strongly connected component, starting from
+// the DI Function with the least name, so that the result does not depend on the order in which
+// DI Functions are added.
+func (p *Program) findCycle(component []int) DependencyCycle {
+	isInComponent := make(map[int]bool, len(component))
+	for _, functionIndex := range component {
+		isInComponent[functionIndex] = true
+	}
+	sortedDependencies := func(functionIndex int) []dependency {
+		dependencies := p.dependencies(&p.functions[functionIndex])
+		sort.SliceStable(dependencies, func(i, j int) bool {
+			dependency1, dependency2 := &dependencies[i], &dependencies[j]
+			if name1, name2 := p.functions[dependency1.FunctionIndex].Name, p.functions[dependency2.FunctionIndex].Name; name1 != name2 {
+				return name1 < name2
+			}
+			if dependency1.Kind != dependency2.Kind {
+				return dependency1.Kind < dependency2.Kind
+			}
+			return dependency1.ValueRef < dependency2.ValueRef
+		})
+		return dependencies
+	}
+	startFunctionIndex := component[0]
+	for _, functionIndex := range component[1:] {
+		if p.functions[functionIndex].Name < p.functions[startFunctionIndex].Name {
+			startFunctionIndex = functionIndex
+		}
+	}
+	type visit struct {
+		FunctionIndex int
+		Dependency    dependency
+		PrevVisit     *visit
+	}
+	isVisited := make(map[int]bool, len(component))
+	queue := []*visit{{FunctionIndex: startFunctionIndex}}
+	for len(queue) >= 1 {
+		visit1 := queue[0]
+		queue = queue[1:]
+		for _, dependency := range sortedDependencies(visit1.FunctionIndex) {
+			if !isInComponent[dependency.FunctionIndex] {
+				continue
+			}
+			visit2 := &visit{dependency.FunctionIndex, dependency, visit1}
+			if dependency.FunctionIndex == startFunctionIndex {
+				var cycle DependencyCycle
+				for visit := visit2; visit.PrevVisit != nil; visit = visit.PrevVisit {
+					cycle = append(cycle, DependencyStep{
+						FunctionName: p.functions[visit.PrevVisit.FunctionIndex].Name,
+						Kind:         visit.Dependency.Kind,
+						ValueRef:     visit.Dependency.ValueRef,
+						Location:     visit.Dependency.Location,
+					})
+				}
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+			if isVisited[dependency.FunctionIndex] {
+				continue
+			}
+			isVisited[dependency.FunctionIndex] = true
+			queue = append(queue, visit2)
+		}
+	}
+	panic("unreachable code")
 }
 
 func (p *Program) callFunctions(ctx context.Context) error {
 	for _, functionIndex := range p.sortedFunctionIndexes {
 		function := &p.functions[functionIndex]
-		for _, argumentIndex := range function.ArgumentIndexes {
-			argument := &p.arguments[argumentIndex]
-			if argument.ResultIndex < 0 {
-				continue
-			}
-			result := &p.results[argument.ResultIndex]
-			if argument.ReceiveValueAddr {
-				argument.ValueReceiver.Set(result.Value.Addr())
-			} else {
-				argument.ValueReceiver.Set(result.Value)
+		if function.IsTransient {
+			// The body of a transient DI Function is called for each consumer instead.
+			p.mutex.Lock()
+			p.functionTimings = append(p.functionTimings, functionTiming{FunctionIndex: functionIndex})
+			p.calledFunctionCount++
+			p.mutex.Unlock()
+			continue
+		}
+		unmetCondition, err := p.evaluateConditions(ctx, function)
+		if err != nil {
+			return err
+		}
+		if unmetCondition != nil {
+			p.mutex.Lock()
+			if p.disabledFunctions == nil {
+				p.disabledFunctions = make(map[int]*condition)
 			}
+			p.disabledFunctions[functionIndex] = unmetCondition
+			p.functionTimings = append(p.functionTimings, functionTiming{FunctionIndex: functionIndex})
+			p.calledFunctionCount++
+			p.mutex.Unlock()
+			continue
+		}
+		if err := p.setArguments(ctx, function); err != nil {
+			return err
 		}
-		if err := function.Body(ctx); err != nil {
-			return fmt.Errorf("call function; functionName=%q: %w", function.Name, err)
+		p.mutex.Lock()
+		p.functionTimings = append(p.functionTimings, functionTiming{FunctionIndex: functionIndex})
+		functionTiming := &p.functionTimings[len(p.functionTimings)-1]
+		p.mutex.Unlock()
+		p.reportProgress(function.Name, PhaseBody)
+		if err := p.callBody(ctx, function, functionTiming); err != nil {
+			return err
 		}
+		p.mutex.Lock()
 		p.calledFunctionCount++
+		p.functionCalls = append(p.functionCalls, functionCall{FunctionIndex: functionIndex})
+		p.mutex.Unlock()
 		for _, resultIndex := range function.ResultIndexes {
 			result := &p.results[resultIndex]
 			for _, hookIndex := range result.HookIndexes {
 				hook := &p.hooks[hookIndex]
+				if p.isDisabled(hook.FunctionIndex) {
+					continue
+				}
 				if hook.ReceiveValueAddr {
 					hook.ValueReceiver.Set(result.Value.Addr())
 				} else {
 					hook.ValueReceiver.Set(result.Value)
 				}
-				if err := hook.Callback(ctx); err != nil {
-					function2 := &p.functions[hook.FunctionIndex]
-					return fmt.Errorf("do callback; functionName=%q valueRef=%q: %w", function2.Name, hook.ValueRef, err)
+				p.mutex.Lock()
+				functionTiming.HookTimings = append(functionTiming.HookTimings, hookTiming{HookIndex: hookIndex})
+				hookTiming := &functionTiming.HookTimings[len(functionTiming.HookTimings)-1]
+				p.mutex.Unlock()
+				p.reportProgress(p.functions[hook.FunctionIndex].Name, PhaseHook)
+				if err := p.callHook(ctx, hook, function.Name, hookTiming); err != nil {
+					return err
 				}
 			}
 		}
+		for _, hookIndex := range function.HookIndexes {
+			hook := &p.hooks[hookIndex]
+			if !hook.InheritedValue.IsValid() {
+				continue
+			}
+			if hook.ReceiveValueAddr {
+				hook.ValueReceiver.Set(hook.InheritedValue.Addr())
+			} else {
+				hook.ValueReceiver.Set(hook.InheritedValue)
+			}
+			p.mutex.Lock()
+			functionTiming.HookTimings = append(functionTiming.HookTimings, hookTiming{HookIndex: hookIndex})
+			hookTiming := &functionTiming.HookTimings[len(functionTiming.HookTimings)-1]
+			p.mutex.Unlock()
+			p.reportProgress(function.Name, PhaseHook)
+			if err := p.callHook(ctx, hook, hook.InheritedProducerName, hookTiming); err != nil {
+				return err
+			}
+		}
 	}
+	p.reportProgress("", "")
 	return nil
 }
 
+// setArguments sets arguments of the given DI Function to the values referenced, calling bodies
+// of transient DI Functions producing the values.
+func (p *Program) setArguments(ctx context.Context, function *function) error {
+	for _, argumentIndex := range function.ArgumentIndexes {
+		argument := &p.arguments[argumentIndex]
+		if argument.IsCondition {
+			// Set by evaluateConditions() already.
+			continue
+		}
+		if err := p.setArgument(ctx, function, argument); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Program) setArgument(ctx context.Context, function *function, argument *argument) error {
+	var value reflect.Value
+	if argument.ResultIndex >= 0 {
+		result := &p.results[argument.ResultIndex]
+		producer := &p.functions[result.FunctionIndex]
+		if condition, ok := p.disabledFunctions[producer.Index]; ok {
+			if argument.IsOptional {
+				return nil
+			}
+			producerDisabledError := ProducerDisabledError{
+				ValueRef:          argument.ValueRef,
+				ProducerName:      producer.Name,
+				ConditionLocation: condition.Location,
+				FunctionName:      function.Name,
+				ModuleName:        function.ModuleName,
+				Location:          argument.Location,
+			}
+			if condition.ArgumentIndex >= 0 {
+				producerDisabledError.ConditionValueRef = p.arguments[condition.ArgumentIndex].ValueRef
+			}
+			return &producerDisabledError
+		}
+		if producer.IsTransient {
+			if err := p.callTransient(ctx, producer, function); err != nil {
+				return err
+			}
+			if argument.ReceiveValueAddr {
+				// Each consumer receives the address of its own copy of the value.
+				valuePtr := reflect.New(result.Value.Type())
+				valuePtr.Elem().Set(result.Value)
+				argument.ValueReceiver.Set(valuePtr)
+				return nil
+			}
+		}
+		value = result.Value
+	} else if argument.InheritedValue.IsValid() {
+		value = argument.InheritedValue
+	} else {
+		return nil
+	}
+	if argument.ReceiveValueAddr {
+		argument.ValueReceiver.Set(value.Addr())
+	} else {
+		argument.ValueReceiver.Set(value)
+	}
+	return nil
+}
+
+func (p *Program) callBody(ctx context.Context, function *function, functionTiming *functionTiming) error {
+	observer := p.observer()
+	event := p.newFunctionEvent(function)
+	ctx, span := p.startFunctionSpan(ctx, "di.body", event)
+	event.StartTime = p.timeNow()
+	observer.OnFunctionStart(ctx, event)
+	var err error
+	p.doWithPprofLabels(ctx, function.Name, PhaseBody, strings.Join(event.ValueNames, ","), func(ctx context.Context) {
+		err = function.Body(ctx)
+	})
+	endSpan(span, err)
+	endTime := p.timeNow()
+	p.mutex.Lock()
+	functionTiming.BodyStartTime = event.StartTime
+	functionTiming.BodyEndTime = endTime
+	functionTiming.Err = err
+	p.mutex.Unlock()
+	event.Duration = endTime.Sub(event.StartTime)
+	event.Err = err
+	observer.OnFunctionEnd(ctx, event)
+	if err != nil {
+		return &FunctionCallError{
+			FunctionName: function.Name,
+			ModuleName:   function.ModuleName,
+			Location:     function.Location,
+			Err:          err,
+		}
+	}
+	return nil
+}
+
+func (p *Program) callHook(ctx context.Context, hook *hook, producerName string, hookTiming *hookTiming) error {
+	observer := p.observer()
+	function := &p.functions[hook.FunctionIndex]
+	event := HookEvent{
+		FunctionName: function.Name,
+		ValueRef:     hook.ValueRef,
+		Location:     hook.Location,
+		ProducerName: producerName,
+	}
+	ctx, span := p.tracer().Start(ctx, "di.hook")
+	span.SetAttributes(
+		Attribute{AttributeFunction, function.Name},
+		Attribute{AttributeLocation, hook.Location.String()},
+		Attribute{AttributeValueRef, hook.ValueRef},
+	)
+	event.StartTime = p.timeNow()
+	observer.OnHookStart(ctx, event)
+	var err error
+	p.doWithPprofLabels(ctx, function.Name, PhaseHook, hook.ValueRef, func(ctx context.Context) {
+		err = hook.Callback(ctx)
+	})
+	endSpan(span, err)
+	endTime := p.timeNow()
+	p.mutex.Lock()
+	hookTiming.StartTime = event.StartTime
+	hookTiming.EndTime = endTime
+	hookTiming.Err = err
+	p.mutex.Unlock()
+	event.Duration = endTime.Sub(event.StartTime)
+	event.Err = err
+	observer.OnHookEnd(ctx, event)
+	if err != nil {
+		return &HookCallbackError{
+			FunctionName: function.Name,
+			ModuleName:   function.ModuleName,
+			ValueRef:     hook.ValueRef,
+			Location:     hook.Location,
+			Err:          err,
+		}
+	}
+	return nil
+}
+
+func (p *Program) newFunctionEvent(function *function) FunctionEvent {
+	event := FunctionEvent{
+		FunctionName: function.Name,
+		Location:     function.Location,
+	}
+	for _, argumentIndex := range function.ArgumentIndexes {
+		event.ValueRefs = append(event.ValueRefs, p.arguments[argumentIndex].ValueRef)
+	}
+	for _, resultIndex := range function.ResultIndexes {
+		event.ValueNames = append(event.ValueNames, p.results[resultIndex].ValueName)
+	}
+	return event
+}
+
+func (p *Program) startFunctionSpan(ctx context.Context, spanName string, event FunctionEvent) (context.Context, Span) {
+	ctx, span := p.tracer().Start(ctx, spanName)
+	span.SetAttributes(
+		Attribute{AttributeFunction, event.FunctionName},
+		Attribute{AttributeLocation, event.Location.String()},
+		Attribute{AttributeValueNames, strings.Join(event.ValueNames, ",")},
+	)
+	return ctx, span
+}
+
 var (
-	// ErrDuplicateValueName is returned by Program.Run() when a value name used by Result() is duplicate.
+	// ErrDuplicateValueName is returned by Program.Validate()/Program.Run() when a value name used by Result() is duplicate.
 	ErrDuplicateValueName = errors.New("di: duplicate value name")
 
-	// ErrValueNotFound is returned by Program.Run() when a value used by Argument()/Hook() does not exist.
+	// ErrValueNotFound is returned by Program.Validate()/Program.Run() when a value used by Argument()/Hook() does not exist.
 	ErrValueNotFound = errors.New("di: value not found")
 
-	// ErrIncompatibleValueReceiver is returned by Program.Run() when a value receiver used by Argument()/Hook() is incompatible.
+	// ErrIncompatibleValueReceiver is returned by Program.Validate()/Program.Run() when a value receiver used by Argument()/Hook() is incompatible.
 	ErrIncompatibleValueReceiver = errors.New("di: incompatible value receiver")
 
-	// ErrCircularDependencies is returned by Program.Run() when circular dependencies are detected.
+	// ErrCircularDependencies is returned by Program.Validate()/Program.Run() when circular dependencies are detected.
 	ErrCircularDependencies = errors.New("di: circular dependencies")
 )
 
+// WiringErrors is returned by Program.Validate()/Program.Run() when DI Functions are wired incorrectly,
+// it collects all errors detected, sorted by error messages. Each error can be matched with
+// errors.Is()/errors.As() against the WiringErrors.
+type WiringErrors []error
+
+var _ error = WiringErrors(nil)
+
+// Error implements error.Error.
+func (e WiringErrors) Error() string {
+	errStrs := make([]string, len(e))
+	for i, err := range e {
+		errStrs[i] = err.Error()
+	}
+	return strings.Join(errStrs, "\n")
+}
+
+// Is is for errors.Is.
+func (e WiringErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As is for errors.As.
+func (e WiringErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the errors collected.
+func (e WiringErrors) Unwrap() []error { return e }
+
+func (e WiringErrors) sort() {
+	sort.SliceStable(e, func(i, j int) bool { return e[i].Error() < e[j].Error() })
+}
+
 // MustRun likes Run but panics when an error occurs.
 func (p *Program) MustRun(ctx context.Context) {
 	if err := p.Run(ctx); err != nil {
@@ -439,11 +1239,38 @@ func (p *Program) MustRun(ctx context.Context) {
 // Clean calls cleanups of DI Functions, the order in which cleanups are to be called
 // is reversed to the order in which DI Functions are called.
 func (p *Program) Clean() {
-	for i := p.calledFunctionCount - 1; i >= 0; i-- {
-		functionIndex := p.sortedFunctionIndexes[i]
-		function := &p.functions[functionIndex]
-		if cleanup := function.Cleanup; cleanup != nil {
-			cleanup()
+	ctx, span := p.tracer().Start(context.Background(), "di.clean")
+	defer span.End()
+	p.mutex.Lock()
+	functionCalls := p.functionCalls
+	p.cleanedCallCount = 0
+	p.mutex.Unlock()
+	for i := len(functionCalls) - 1; i >= 0; i-- {
+		functionCall := &functionCalls[i]
+		function := &p.functions[functionCall.FunctionIndex]
+		if function.Cleanup != nil {
+			// Restore the results of a call to the body of a transient DI Function.
+			for j, resultValue := range functionCall.ResultValues {
+				p.results[function.ResultIndexes[j]].Value.Set(resultValue)
+			}
+			p.callCleanup(ctx, function)
 		}
+		p.mutex.Lock()
+		p.cleanedCallCount++
+		p.mutex.Unlock()
 	}
 }
+
+func (p *Program) callCleanup(ctx context.Context, function *function) {
+	observer := p.observer()
+	event := p.newFunctionEvent(function)
+	ctx, span := p.startFunctionSpan(ctx, "di.cleanup", event)
+	event.StartTime = p.timeNow()
+	observer.OnCleanupStart(ctx, event)
+	p.doWithPprofLabels(ctx, function.Name, PhaseCleanup, strings.Join(event.ValueNames, ","), func(context.Context) {
+		function.Cleanup()
+	})
+	span.End()
+	event.Duration = p.timeNow().Sub(event.StartTime)
+	observer.OnCleanupEnd(ctx, event)
+}
@@ -2,6 +2,10 @@ package di_test
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
 
 	. "github.com/go-tk/di"
@@ -9,6 +13,34 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var locationPattern = regexp.MustCompile(`/(?:[^"\s,/\\]+/)*([^"\s,/\\]+\.go):\d+`)
+
+// normalizeLocations replaces locations like "/path/to/di_test.go:123" with "di_test.go:*",
+// so that expected error messages do not depend on paths and line numbers.
+func normalizeLocations(s string) string {
+	return locationPattern.ReplaceAllString(s, "$1:*")
+}
+
+func assertEqualError(t *testing.T, err error, errStr string) bool {
+	t.Helper()
+	if !assert.Error(t, err) {
+		return false
+	}
+	return assert.Equal(t, errStr, normalizeLocations(err.Error()))
+}
+
+func assertPanicsWithValue(t *testing.T, value string, f func()) bool {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if assert.NotNil(t, r) {
+			assert.Equal(t, value, normalizeLocations(fmt.Sprint(r)))
+		}
+	}()
+	f()
+	return true
+}
+
 func TestArgument(t *testing.T) {
 	type C struct {
 		functionBuilder FunctionBuilder
@@ -25,7 +57,7 @@ func TestArgument(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -39,7 +71,7 @@ func TestArgument(t *testing.T) {
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Argument("", nil)
 		c.err = ErrInvalidArgument
-		c.errStr = c.err.Error() + `: empty value ref; functionName="github.com/go-tk/di_test.TestArgument.func1"`
+		c.errStr = c.err.Error() + `: empty value ref; functionName="github.com/go-tk/di_test.TestArgument.func1" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -49,7 +81,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Argument("foo", nil)
 		c.err = ErrInvalidArgument
-		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestArgument.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestArgument.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -59,7 +91,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Argument("foo", 0)
 		c.err = ErrInvalidArgument
-		c.errStr = c.err.Error() + `: invalid value receiver pointer; valueReceiverPtrType="int" functionName="github.com/go-tk/di_test.TestArgument.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: invalid value receiver pointer; valueReceiverPtrType="int" functionName="github.com/go-tk/di_test.TestArgument.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -69,7 +101,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Argument("foo", (*string)(nil))
 		c.err = ErrInvalidArgument
-		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestArgument.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestArgument.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -139,7 +171,7 @@ func TestHook(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -153,7 +185,7 @@ func TestHook(t *testing.T) {
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Hook("", nil, nil)
 		c.err = ErrInvalidHook
-		c.errStr = c.err.Error() + `: empty value ref; functionName="github.com/go-tk/di_test.TestHook.func1"`
+		c.errStr = c.err.Error() + `: empty value ref; functionName="github.com/go-tk/di_test.TestHook.func1" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -163,7 +195,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Hook("foo", nil, nil)
 		c.err = ErrInvalidHook
-		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -173,7 +205,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Hook("foo", 0, nil)
 		c.err = ErrInvalidHook
-		c.errStr = c.err.Error() + `: invalid value receiver pointer; valueReceiverPtrType="int" functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: invalid value receiver pointer; valueReceiverPtrType="int" functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -183,7 +215,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Hook("foo", (*string)(nil), nil)
 		c.err = ErrInvalidHook
-		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: no value receiver; functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -193,7 +225,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Hook("foo", new(string), nil)
 		c.err = ErrInvalidHook
-		c.errStr = c.err.Error() + `: nil callback; functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo"`
+		c.errStr = c.err.Error() + `: nil callback; functionName="github.com/go-tk/di_test.TestHook.func1" valueRef="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -239,7 +271,7 @@ func TestResult(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -253,7 +285,7 @@ func TestResult(t *testing.T) {
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Result("", nil)
 		c.err = ErrInvalidResult
-		c.errStr = c.err.Error() + `: empty value name; functionName="github.com/go-tk/di_test.TestResult.func1"`
+		c.errStr = c.err.Error() + `: empty value name; functionName="github.com/go-tk/di_test.TestResult.func1" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -263,7 +295,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Result("foo", nil)
 		c.err = ErrInvalidResult
-		c.errStr = c.err.Error() + `: no value; functionName="github.com/go-tk/di_test.TestResult.func1" valueName="foo"`
+		c.errStr = c.err.Error() + `: no value; functionName="github.com/go-tk/di_test.TestResult.func1" valueName="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -273,7 +305,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Result("foo", 0)
 		c.err = ErrInvalidResult
-		c.errStr = c.err.Error() + `: invalid value pointer; valuePtrType="int" functionName="github.com/go-tk/di_test.TestResult.func1" valueName="foo"`
+		c.errStr = c.err.Error() + `: invalid value pointer; valuePtrType="int" functionName="github.com/go-tk/di_test.TestResult.func1" valueName="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -283,7 +315,7 @@ CalledFunctionCount: 0
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Result("foo", (*string)(nil))
 		c.err = ErrInvalidResult
-		c.errStr = c.err.Error() + `: no value; functionName="github.com/go-tk/di_test.TestResult.func1" valueName="foo"`
+		c.errStr = c.err.Error() + `: no value; functionName="github.com/go-tk/di_test.TestResult.func1" valueName="foo" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -328,7 +360,7 @@ func TestBody(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -342,7 +374,7 @@ func TestBody(t *testing.T) {
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Body(nil)
 		c.err = ErrNilBody
-		c.errStr = c.err.Error() + `; functionName="github.com/go-tk/di_test.TestBody.func1"`
+		c.errStr = c.err.Error() + `; functionName="github.com/go-tk/di_test.TestBody.func1" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -382,7 +414,7 @@ func TestCleanup(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -396,7 +428,7 @@ func TestCleanup(t *testing.T) {
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.functionBuilder = Cleanup(nil)
 		c.err = ErrNilCleanup
-		c.errStr = c.err.Error() + `; functionName="github.com/go-tk/di_test.TestCleanup.func1"`
+		c.errStr = c.err.Error() + `; functionName="github.com/go-tk/di_test.TestCleanup.func1" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -436,7 +468,7 @@ func TestNewFunction(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -449,7 +481,7 @@ func TestNewFunction(t *testing.T) {
 
 	tc.WithCallback("0", func(t *testing.T, c *C) {
 		c.err = ErrBodyRequired
-		c.errStr = c.err.Error() + `; functionName="github.com/go-tk/di_test.TestNewFunction.func1"`
+		c.errStr = c.err.Error() + `; functionName="github.com/go-tk/di_test.TestNewFunction.func1" location="di_test.go:*"`
 		c.repr = `
 SortedFunctionIndexes: []
 CalledFunctionCount: 0
@@ -531,7 +563,7 @@ func TestProgram_MustNewFunction(t *testing.T) {
 		var p Program
 		p.MustNewFunction(Body(func(context.Context) error { return nil }))
 	}
-	assert.PanicsWithValue(t, `new function: di: body required; functionName="github.com/go-tk/di_test.TestProgram_MustNewFunction.func2"`, func() {
+	assertPanicsWithValue(t, `new function: di: body required; functionName="github.com/go-tk/di_test.TestProgram_MustNewFunction.func2" location="di_test.go:*"`, func() {
 		var p Program
 		p.MustNewFunction()
 	})
@@ -558,7 +590,7 @@ func TestProgram_Run(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -585,7 +617,7 @@ func TestProgram_Run(t *testing.T) {
 			}
 		}()
 		c.err = ErrDuplicateValueName
-		c.errStr = c.err.Error() + `; valueName="res1" functionName1="github.com/go-tk/di_test.TestProgram_Run.func2.2" functionName2="github.com/go-tk/di_test.TestProgram_Run.func2.1"`
+		c.errStr = c.err.Error() + `; valueName="res1" functionName1="github.com/go-tk/di_test.TestProgram_Run.func2.2" functionName2="github.com/go-tk/di_test.TestProgram_Run.func2.1" location1="di_test.go:*" location2="di_test.go:*"`
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -627,7 +659,7 @@ CalledFunctionCount: 0
 			}
 		}()
 		c.err = ErrValueNotFound
-		c.errStr = c.err.Error() + `; valueRef="arg" functionName="github.com/go-tk/di_test.TestProgram_Run.func3.1"`
+		c.errStr = c.err.Error() + `; valueRef="arg" functionName="github.com/go-tk/di_test.TestProgram_Run.func3.1" location="di_test.go:*"`
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -694,7 +726,7 @@ CalledFunctionCount: 1
 			}
 		}()
 		c.err = ErrIncompatibleValueReceiver
-		c.errStr = c.err.Error() + `; valueReceiverType="string" valueType="int" valueRef="val" functionName="github.com/go-tk/di_test.TestProgram_Run.func5.2"`
+		c.errStr = c.err.Error() + `; valueReceiverType="string" valueType="int" valueRef="val" functionName="github.com/go-tk/di_test.TestProgram_Run.func5.2" location="di_test.go:*"`
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -739,7 +771,7 @@ CalledFunctionCount: 0
 			}
 		}()
 		c.err = ErrValueNotFound
-		c.errStr = c.err.Error() + `; valueRef="hook" functionName="github.com/go-tk/di_test.TestProgram_Run.func6.1"`
+		c.errStr = c.err.Error() + `; valueRef="hook" functionName="github.com/go-tk/di_test.TestProgram_Run.func6.1" location="di_test.go:*"`
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -777,7 +809,7 @@ CalledFunctionCount: 0
 			}
 		}()
 		c.err = ErrIncompatibleValueReceiver
-		c.errStr = c.err.Error() + `; valueReceiverType="string" valueType="int" valueRef="val" functionName="github.com/go-tk/di_test.TestProgram_Run.func7.2"`
+		c.errStr = c.err.Error() + `; valueReceiverType="string" valueType="int" valueRef="val" functionName="github.com/go-tk/di_test.TestProgram_Run.func7.2" location="di_test.go:*"`
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -821,10 +853,10 @@ CalledFunctionCount: 0
 			}
 		}()
 		c.err = ErrCircularDependencies
-		c.errStr = c.err.Error() + `; path="github.com/go-tk/di_test.TestProgram_Run.func8.1@argument:val => github.com/go-tk/di_test.TestProgram_Run.func8.1"`
+		c.errStr = c.err.Error() + `; path="github.com/go-tk/di_test.TestProgram_Run.func8.1@argument:val => github.com/go-tk/di_test.TestProgram_Run.func8.1" locations="di_test.go:*"`
 		c.repr = `
 Function[0]:
-	Index: -1
+	Index: 0
 	Name: github.com/go-tk/di_test.TestProgram_Run.func8.1
 	ArgumentIndexes: [0]
 	ResultIndexes: [0]
@@ -859,10 +891,10 @@ CalledFunctionCount: 0
 			}
 		}()
 		c.err = ErrCircularDependencies
-		c.errStr = c.err.Error() + `; path="github.com/go-tk/di_test.TestProgram_Run.func9.1@hook:val => github.com/go-tk/di_test.TestProgram_Run.func9.1"`
+		c.errStr = c.err.Error() + `; path="github.com/go-tk/di_test.TestProgram_Run.func9.1@hook:val => github.com/go-tk/di_test.TestProgram_Run.func9.1" locations="di_test.go:*"`
 		c.repr = `
 Function[0]:
-	Index: -1
+	Index: 0
 	Name: github.com/go-tk/di_test.TestProgram_Run.func9.1
 	ArgumentIndexes: []
 	ResultIndexes: [0]
@@ -1191,7 +1223,7 @@ CalledFunctionCount: 3
 			}
 		}()
 		c.err = context.DeadlineExceeded
-		c.errStr = `call function; functionName="github.com/go-tk/di_test.TestProgram_Run.func13.1": ` + c.err.Error()
+		c.errStr = `call function; functionName="github.com/go-tk/di_test.TestProgram_Run.func13.1" location="di_test.go:*": ` + c.err.Error()
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -1229,7 +1261,7 @@ CalledFunctionCount: 0
 			}
 		}()
 		c.err = context.Canceled
-		c.errStr = `do callback; functionName="github.com/go-tk/di_test.TestProgram_Run.func14.2" valueRef="x": ` + c.err.Error()
+		c.errStr = `do callback; functionName="github.com/go-tk/di_test.TestProgram_Run.func14.2" valueRef="x" location="di_test.go:*": ` + c.err.Error()
 		c.repr = `
 Function[0]:
 	Index: 0
@@ -1262,6 +1294,189 @@ SortedFunctionIndexes: [1 0]
 CalledFunctionCount: 2
 `[1:]
 	}).RunParallel(t)
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		func() {
+			var x int
+			err := c.p.NewFunction(Argument("y", &x), Argument("z", &x), Result("x", &x),
+				Body(func(context.Context) error { return nil }))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}()
+		func() {
+			var x int
+			var y string
+			err := c.p.NewFunction(Result("x", &x), Body(func(context.Context) error { return nil }),
+				Hook("x", &y, func(context.Context) error { return nil }),
+				Hook("w", &y, func(context.Context) error { return nil }))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}()
+		c.err = ErrValueNotFound
+		c.errStr = ErrDuplicateValueName.Error() + `; valueName="x" functionName1="github.com/go-tk/di_test.TestProgram_Run.func15.2" functionName2="github.com/go-tk/di_test.TestProgram_Run.func15.1" location1="di_test.go:*" location2="di_test.go:*"` + "\n" +
+			ErrIncompatibleValueReceiver.Error() + `; valueReceiverType="string" valueType="int" valueRef="x" functionName="github.com/go-tk/di_test.TestProgram_Run.func15.2" location="di_test.go:*"` + "\n" +
+			ErrValueNotFound.Error() + `; valueRef="w" functionName="github.com/go-tk/di_test.TestProgram_Run.func15.2" location="di_test.go:*"` + "\n" +
+			ErrValueNotFound.Error() + `; valueRef="y" functionName="github.com/go-tk/di_test.TestProgram_Run.func15.1" location="di_test.go:*"` + "\n" +
+			ErrValueNotFound.Error() + `; valueRef="z" functionName="github.com/go-tk/di_test.TestProgram_Run.func15.1" location="di_test.go:*"`
+		c.repr = `
+Function[0]:
+	Index: 0
+	Name: github.com/go-tk/di_test.TestProgram_Run.func15.1
+	ArgumentIndexes: [0 1]
+	ResultIndexes: [0]
+	HasBody: true
+	HookIndexes: []
+	HasCleanup: false
+Function[1]:
+	Index: 1
+	Name: github.com/go-tk/di_test.TestProgram_Run.func15.2
+	ArgumentIndexes: []
+	ResultIndexes: [1]
+	HasBody: true
+	HookIndexes: [0 1]
+	HasCleanup: false
+Argument[0]:
+	FunctionIndex: 0
+	ValueRef: y
+	HasValueReceiver: true
+	IsOptional: false
+	ResultIndex: -1
+	ReceiveValueAddr: false
+Argument[1]:
+	FunctionIndex: 0
+	ValueRef: z
+	HasValueReceiver: true
+	IsOptional: false
+	ResultIndex: -1
+	ReceiveValueAddr: false
+Result[0]:
+	FunctionIndex: 0
+	ValueName: x
+	HasValue: true
+	HookIndexes: []
+Result[1]:
+	FunctionIndex: 1
+	ValueName: x
+	HasValue: true
+	HookIndexes: []
+Hook[0]:
+	FunctionIndex: 1
+	ValueRef: x
+	HasValueReceiver: true
+	HasCallback: true
+	ReceiveValueAddr: false
+Hook[1]:
+	FunctionIndex: 1
+	ValueRef: w
+	HasValueReceiver: true
+	HasCallback: true
+	ReceiveValueAddr: false
+SortedFunctionIndexes: []
+CalledFunctionCount: 0
+`[1:]
+	}).RunParallel(t)
+}
+
+func TestProgram_Validate(t *testing.T) {
+	var p Program
+	var x, y int
+	p.MustNewFunction(Argument("y", &y), Body(func(context.Context) error { return nil }))
+	p.MustNewFunction(Argument("x", &x), Body(func(context.Context) error { return nil }))
+	err := p.Validate()
+	var errs WiringErrors
+	if assert.ErrorAs(t, err, &errs) {
+		assert.Len(t, errs, 2)
+		for _, err := range errs {
+			assert.ErrorIs(t, err, ErrValueNotFound)
+		}
+	}
+	p.MustNewFunction(Result("x", &x), Body(func(context.Context) error { x = 1; return nil }))
+	p.MustNewFunction(Result("y", &y), Body(func(context.Context) error { y = 2; return nil }))
+	assert.NoError(t, p.Validate())
+	assert.NoError(t, p.Validate())
+	repr := p.DumpAsString()
+	assert.Equal(t, "SortedFunctionIndexes: [3 0 2 1]\nCalledFunctionCount: 0\n", repr[strings.Index(repr, "Sorted"):])
+	assert.NoError(t, p.Run(context.Background()))
+	assert.Equal(t, 1, x)
+	assert.Equal(t, 2, y)
+}
+
+func TestProgram_FindCycles(t *testing.T) {
+	var p Program
+	newFunction := func(name string, builders ...FunctionBuilder) {
+		builders = append(builders, Body(func(context.Context) error { return nil }))
+		if err := p.DoNewFunction(name, builders...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var a, b, c, d, e int
+	newFunction("F3", Argument("a", &a), Result("c", &c))
+	newFunction("F1", Argument("c", &c), Result("a", &a))
+	newFunction("F2", Argument("a", &a), Result("b", &b))
+	newFunction("F5", Argument("d", &d), Hook("e", &e, func(context.Context) error { return nil }))
+	newFunction("F4", Result("d", &d), Result("e", &e))
+	newFunction("F6", Argument("b", &b), Argument("d", &d))
+	cycles := p.FindCycles()
+	for _, cycle := range cycles {
+		for i := range cycle {
+			cycle[i].Location = Location{}
+		}
+	}
+	assert.Equal(t, []DependencyCycle{
+		{
+			{FunctionName: "F1", Kind: DependencyArgument, ValueRef: "c"},
+			{FunctionName: "F3", Kind: DependencyArgument, ValueRef: "a"},
+		},
+		{
+			{FunctionName: "F4", Kind: DependencyHook, ValueRef: "e"},
+			{FunctionName: "F5", Kind: DependencyArgument, ValueRef: "d"},
+		},
+	}, cycles)
+	err := p.Validate()
+	assertEqualError(t, err, ErrCircularDependencies.Error()+`; path="F1@argument:c => F3@argument:a => F1" locations="di_test.go:*, di_test.go:*"`+"\n"+
+		ErrCircularDependencies.Error()+`; path="F4@hook:e => F5@argument:d => F4" locations="di_test.go:*, di_test.go:*"`)
+	assert.ErrorIs(t, err, ErrCircularDependencies)
+}
+
+func TestLocation(t *testing.T) {
+	var p Program
+	var x, y int
+	_, file, line, _ := runtime.Caller(0)
+	err := p.NewFunction(Argument("y", &y), Result("x", &x), Body(func(context.Context) error { return nil }))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	location := Location{File: file, Line: line + 1}
+	graph := p.Graph()
+	assert.Equal(t, location, graph.Functions[0].Location)
+	assert.Equal(t, location, graph.Functions[0].Arguments[0].Location)
+	assert.Equal(t, location, graph.Functions[0].Results[0].Location)
+	assert.EqualError(t, p.Validate(), fmt.Sprintf(`%v; valueRef="y" functionName="github.com/go-tk/di_test.TestLocation" location="%v"`,
+		ErrValueNotFound, location))
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	var p Program
+	var x, y, z int
+	p.MustNewFunction(Result("x", &x), Result("y", &y), Body(func(context.Context) error { return nil }))
+	p.MustNewFunction(Argument("x", &x), OptionalArgument("y", &y), Argument("z", &z),
+		Body(func(context.Context) error { return nil }),
+		Hook("y", &y, func(context.Context) error { return nil }))
+	var builder strings.Builder
+	if !assert.NoError(t, p.Graph().WriteDOT(&builder)) {
+		t.FailNow()
+	}
+	assert.Equal(t, `
+digraph di {
+	node [shape=box];
+	f0 [label="github.com/go-tk/di_test.TestGraph_WriteDOT\ndi_test.go:*"];
+	f1 [label="github.com/go-tk/di_test.TestGraph_WriteDOT\ndi_test.go:*"];
+	f0 -> f1 [label="x", tooltip="di_test.go:*", style=solid];
+	f0 -> f1 [label="y", tooltip="di_test.go:*", style=dotted];
+	f0 -> f1 [label="y", tooltip="di_test.go:*", style=dashed];
+}
+`[1:], normalizeLocations(builder.String()))
 }
 
 func TestProgram_MustRun(t *testing.T) {
@@ -1269,7 +1484,7 @@ func TestProgram_MustRun(t *testing.T) {
 		var p Program
 		p.MustRun(context.Background())
 	}
-	assert.PanicsWithValue(t, `run program: di: value not found; valueRef="x" functionName="github.com/go-tk/di_test.TestProgram_MustRun.func1"`, func() {
+	assertPanicsWithValue(t, `run program: di: value not found; valueRef="x" functionName="github.com/go-tk/di_test.TestProgram_MustRun.func1" location="di_test.go:*"`, func() {
 		var p Program
 		var x int
 		p.MustNewFunction(Argument("x", &x), Body(func(context.Context) error { return nil }))
@@ -1298,7 +1513,7 @@ func TestProgram_Clean(t *testing.T) {
 		if c.errStr == "" {
 			assert.NoError(t, err)
 		} else {
-			assert.EqualError(t, err, c.errStr)
+			assertEqualError(t, err, c.errStr)
 			if c.err != nil {
 				assert.ErrorIs(t, err, c.err)
 			}
@@ -1385,7 +1600,7 @@ func TestProgram_Clean(t *testing.T) {
 				t.Fatal(err)
 			}
 		}()
-		c.errStr = `do callback; functionName="github.com/go-tk/di_test.TestProgram_Clean.func4.3" valueRef="y": ` + context.Canceled.Error()
+		c.errStr = `do callback; functionName="github.com/go-tk/di_test.TestProgram_Clean.func4.3" valueRef="y" location="di_test.go:*": ` + context.Canceled.Error()
 	}).WithCallback("1", func(t *testing.T, c *C) {
 		assert.Equal(t, c.seq, "ECFDG")
 	}).RunParallel(t)
@@ -1425,7 +1640,7 @@ func TestProgram_Clean(t *testing.T) {
 				t.Fatal(err)
 			}
 		}()
-		c.errStr = `call function; functionName="github.com/go-tk/di_test.TestProgram_Clean.func6.1": ` + context.Canceled.Error()
+		c.errStr = `call function; functionName="github.com/go-tk/di_test.TestProgram_Clean.func6.1" location="di_test.go:*": ` + context.Canceled.Error()
 	}).WithCallback("1", func(t *testing.T, c *C) {
 		assert.Equal(t, c.seq, "ECFADG")
 	}).RunParallel(t)
@@ -0,0 +1,215 @@
+package di
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WiringDiff is the result of Diff().
+type WiringDiff struct {
+	// Added are entries present in the second Program only.
+	Added []DiffEntry
+
+	// Removed are entries present in the first Program only.
+	Removed []DiffEntry
+
+	// Changed are entries present in both Programs but different.
+	Changed []DiffEntry
+}
+
+// DiffEntry describes a value, an argument or a hook different between two Programs.
+type DiffEntry struct {
+	// Kind is one of "value", "argument" and "hook".
+	Kind string
+
+	// FunctionName is the name of the DI Function producing the value, or the DI Function
+	// having the argument or the hook. For a value whose producer changed, it is the name of
+	// the DI Function in the second Program.
+	FunctionName string
+
+	ValueName string
+
+	// Details describes the entry, or what changed for a changed entry,
+	// e.g. "optional: false => true".
+	Details string
+
+	relatedValueNames []string
+}
+
+// Diff compares the wiring of two Programs, including values with their types and producers,
+// arguments with their types and optionality, and hooks with their types. DI Functions are
+// matched by names. DI Functions overridden or excluded by profiles are ignored.
+func Diff(a, b *Program) *WiringDiff {
+	entries1, entries2 := diffEntries(a), diffEntries(b)
+	var diff WiringDiff
+	for key, entry1 := range entries1 {
+		entry2, ok := entries2[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, entry1)
+			continue
+		}
+		if entry1.Details == entry2.Details && entry1.FunctionName == entry2.FunctionName {
+			continue
+		}
+		var changes []string
+		if entry1.FunctionName != entry2.FunctionName {
+			changes = append(changes, fmt.Sprintf("producer: %s => %s", entry1.FunctionName, entry2.FunctionName))
+		}
+		if entry1.Details != entry2.Details {
+			changes = append(changes, diffDetails(entry1.Details, entry2.Details))
+		}
+		entry2.Details = strings.Join(changes, "; ")
+		entry2.relatedValueNames = append(entry2.relatedValueNames, entry1.relatedValueNames...)
+		diff.Changed = append(diff.Changed, entry2)
+	}
+	for key, entry2 := range entries2 {
+		if _, ok := entries1[key]; !ok {
+			diff.Added = append(diff.Added, entry2)
+		}
+	}
+	for _, entries := range [][]DiffEntry{diff.Added, diff.Removed, diff.Changed} {
+		sortDiffEntries(entries)
+	}
+	return &diff
+}
+
+func diffEntries(p *Program) map[string]DiffEntry {
+	graph := p.Graph()
+	entries := make(map[string]DiffEntry)
+	addEntry := func(key string, entry DiffEntry) {
+		for i := 2; ; i++ {
+			if _, ok := entries[key]; !ok {
+				break
+			}
+			key = fmt.Sprintf("%s#%d", strings.SplitN(key, "#", 2)[0], i)
+		}
+		entries[key] = entry
+	}
+	for i := range graph.Functions {
+		functionInfo := &graph.Functions[i]
+		if !functionInfo.IsActive {
+			continue
+		}
+		var valueNames []string
+		for _, resultInfo := range functionInfo.Results {
+			valueNames = append(valueNames, resultInfo.ValueName)
+		}
+		for _, resultInfo := range functionInfo.Results {
+			addEntry("value\x00"+resultInfo.ValueName, DiffEntry{
+				Kind:              "value",
+				FunctionName:      functionInfo.Name,
+				ValueName:         resultInfo.ValueName,
+				Details:           fmt.Sprintf("type: %v", resultInfo.ValueType),
+				relatedValueNames: valueNames,
+			})
+		}
+		for _, argumentInfo := range functionInfo.Arguments {
+			addEntry("argument\x00"+functionInfo.Name+"\x00"+argumentInfo.ValueRef, DiffEntry{
+				Kind:              "argument",
+				FunctionName:      functionInfo.Name,
+				ValueName:         argumentInfo.ValueRef,
+				Details:           fmt.Sprintf("type: %v; optional: %v", argumentInfo.ValueReceiverType, argumentInfo.IsOptional),
+				relatedValueNames: valueNames,
+			})
+		}
+		for _, hookInfo := range functionInfo.Hooks {
+			addEntry("hook\x00"+functionInfo.Name+"\x00"+hookInfo.ValueRef, DiffEntry{
+				Kind:              "hook",
+				FunctionName:      functionInfo.Name,
+				ValueName:         hookInfo.ValueRef,
+				Details:           fmt.Sprintf("type: %v", hookInfo.ValueReceiverType),
+				relatedValueNames: valueNames,
+			})
+		}
+	}
+	return entries
+}
+
+// diffDetails turns "a: 1; b: 2" and "a: 1; b: 3" into "b: 2 => 3".
+func diffDetails(details1 string, details2 string) string {
+	fields1 := strings.Split(details1, "; ")
+	fields2 := strings.Split(details2, "; ")
+	var changes []string
+	for i := range fields2 {
+		if fields1[i] == fields2[i] {
+			continue
+		}
+		key, value2 := splitDetailsField(fields2[i])
+		_, value1 := splitDetailsField(fields1[i])
+		changes = append(changes, fmt.Sprintf("%s: %s => %s", key, value1, value2))
+	}
+	return strings.Join(changes, "; ")
+}
+
+func splitDetailsField(field string) (string, string) {
+	i := strings.Index(field, ": ")
+	return field[:i], field[i+2:]
+}
+
+func sortDiffEntries(entries []DiffEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		entry1, entry2 := &entries[i], &entries[j]
+		if entry1.ValueName != entry2.ValueName {
+			return entry1.ValueName < entry2.ValueName
+		}
+		if entry1.Kind != entry2.Kind {
+			return entry1.Kind < entry2.Kind
+		}
+		return entry1.FunctionName < entry2.FunctionName
+	})
+}
+
+// IsEmpty returns whether there is no difference.
+func (d *WiringDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Except returns a copy of the diff with entries related to the given values removed, that is
+// entries of the values, and entries of arguments and hooks referencing the values or belonging
+// to DI Functions producing the values.
+func (d *WiringDiff) Except(valueNames ...string) *WiringDiff {
+	isExcepted := make(map[string]bool, len(valueNames))
+	for _, valueName := range valueNames {
+		isExcepted[valueName] = true
+	}
+	filter := func(entries []DiffEntry) []DiffEntry {
+		var filteredEntries []DiffEntry
+	Loop:
+		for _, entry := range entries {
+			if isExcepted[entry.ValueName] {
+				continue
+			}
+			for _, valueName := range entry.relatedValueNames {
+				if isExcepted[valueName] {
+					continue Loop
+				}
+			}
+			filteredEntries = append(filteredEntries, entry)
+		}
+		return filteredEntries
+	}
+	return &WiringDiff{
+		Added:   filter(d.Added),
+		Removed: filter(d.Removed),
+		Changed: filter(d.Changed),
+	}
+}
+
+// String returns the diff in text, one entry per line.
+func (d *WiringDiff) String() string {
+	var builder strings.Builder
+	for _, x := range []struct {
+		Prefix  string
+		Entries []DiffEntry
+	}{
+		{"+", d.Added},
+		{"-", d.Removed},
+		{"~", d.Changed},
+	} {
+		for _, entry := range x.Entries {
+			fmt.Fprintf(&builder, "%s %s %s (%s): %s\n", x.Prefix, entry.Kind, entry.ValueName, entry.FunctionName, entry.Details)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	body := Body(func(context.Context) error { return nil })
+	newProgram := func(isTest bool) *Program {
+		var p Program
+		var config, mailer, db int
+		var metrics string
+		p.MustDoNewFunction("provideConfig", Result("CONFIG", &config), body)
+		if isTest {
+			p.MustDoNewFunction("provideFakeMailer", Result("MAILER", &mailer), body)
+		} else {
+			p.MustDoNewFunction("provideMailer", Argument("CONFIG", &config), Argument("SMTP", &config),
+				Result("MAILER", &mailer), body)
+		}
+		p.MustDoNewFunction("provideDB", Argument("CONFIG", &config), Result("DB", &db), body)
+		if isTest {
+			p.MustDoNewFunction("serve", Argument("MAILER", &mailer), OptionalArgument("DB", &db), body)
+		} else {
+			p.MustDoNewFunction("serve", Argument("MAILER", &mailer), Argument("DB", &db),
+				Hook("DB", &db, func(context.Context) error { return nil }), body)
+			p.MustDoNewFunction("provideMetrics", Result("METRICS", &metrics), body)
+		}
+		return &p
+	}
+	diff := Diff(newProgram(false), newProgram(true))
+	assert.Equal(t, `
+- argument CONFIG (provideMailer): type: int; optional: false
+- hook DB (serve): type: int
+- value METRICS (provideMetrics): type: string
+- argument SMTP (provideMailer): type: int; optional: false
+~ argument DB (serve): optional: false => true
+~ value MAILER (provideFakeMailer): producer: provideMailer => provideFakeMailer
+`[1:], diff.String())
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, `
+- hook DB (serve): type: int
+- value METRICS (provideMetrics): type: string
+~ argument DB (serve): optional: false => true
+`[1:], diff.Except("MAILER").String())
+	assert.True(t, diff.Except("MAILER", "DB", "METRICS").IsEmpty())
+	assert.True(t, Diff(newProgram(true), newProgram(true)).IsEmpty())
+}
+
+func TestDiff_Override(t *testing.T) {
+	body := Body(func(context.Context) error { return nil })
+	newProgram := func() *Program {
+		var p Program
+		var config, mailer int
+		p.MustDoNewFunction("provideConfig", Result("CONFIG", &config), body)
+		p.MustDoNewFunction("provideMailer", Argument("CONFIG", &config), Result("MAILER", &mailer), body)
+		p.MustDoNewFunction("serve", Argument("MAILER", &mailer), body)
+		return &p
+	}
+	p := newProgram()
+	var mailer int
+	p.MustDoOverride("MAILER", "provideFakeMailer", Result("MAILER", &mailer), body)
+	diff := Diff(newProgram(), p)
+	assert.Equal(t, `
+- argument CONFIG (provideMailer): type: int; optional: false
+~ value MAILER (provideFakeMailer): producer: provideMailer => provideFakeMailer
+`[1:], diff.String())
+}
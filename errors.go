@@ -0,0 +1,341 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DuplicateValueNameError is returned by Program.Validate()/Program.Run() when a value name used by
+// Result() is duplicate. It matches ErrDuplicateValueName.
+type DuplicateValueNameError struct {
+	ValueName     string
+	FunctionName1 string
+	FunctionName2 string
+	Location1     Location
+	Location2     Location
+
+	// ActiveProfiles are the profiles under which the value name is duplicate, see Profiles().
+	ActiveProfiles []string
+}
+
+var _ error = (*DuplicateValueNameError)(nil)
+
+// Error implements error.Error.
+func (e *DuplicateValueNameError) Error() string {
+	return fmt.Sprintf("%v; valueName=%q functionName1=%q functionName2=%q location1=%q location2=%q%s",
+		ErrDuplicateValueName, e.ValueName, e.FunctionName1, e.FunctionName2, e.Location1, e.Location2,
+		activeProfilesField(e.ActiveProfiles))
+}
+
+// Is is for errors.Is.
+func (e *DuplicateValueNameError) Is(target error) bool {
+	return target == ErrDuplicateValueName
+}
+
+// ValueNotFoundError is returned by Program.Validate()/Program.Run() when a value used by
+// Argument()/Hook() does not exist. It matches ErrValueNotFound.
+type ValueNotFoundError struct {
+	ValueRef     string
+	FunctionName string
+	ModuleName   string
+	Location     Location
+
+	// Kind indicates whether the value is referenced by an argument or a hook.
+	Kind DependencyKind
+
+	// Suggestions are existing values whose names look like the value ref.
+	Suggestions []Suggestion
+
+	// ActiveProfiles are the profiles under which the value is missing, see Profiles().
+	ActiveProfiles []string
+}
+
+// Suggestion represents an existing value whose name looks like a value ref not found.
+type Suggestion struct {
+	ValueName    string
+	FunctionName string
+}
+
+var _ error = (*ValueNotFoundError)(nil)
+
+// Error implements error.Error.
+func (e *ValueNotFoundError) Error() string {
+	message := fmt.Sprintf("%v; valueRef=%q functionName=%q%s location=%q%s",
+		ErrValueNotFound, e.ValueRef, e.FunctionName, moduleNameField(e.ModuleName), e.Location,
+		activeProfilesField(e.ActiveProfiles))
+	if len(e.Suggestions) >= 1 {
+		suggestionStrs := make([]string, len(e.Suggestions))
+		for i, suggestion := range e.Suggestions {
+			suggestionStrs[i] = fmt.Sprintf("%s (%s)", suggestion.ValueName, suggestion.FunctionName)
+		}
+		message += fmt.Sprintf(" didYouMean=%q", strings.Join(suggestionStrs, ", "))
+	}
+	return message
+}
+
+// Is is for errors.Is.
+func (e *ValueNotFoundError) Is(target error) bool {
+	return target == ErrValueNotFound
+}
+
+// IncompatibleReceiverError is returned by Program.Validate()/Program.Run() when a value receiver used by
+// Argument()/Hook() is incompatible. It matches ErrIncompatibleValueReceiver.
+type IncompatibleReceiverError struct {
+	ValueRef          string
+	ValueReceiverType reflect.Type
+	ValueType         reflect.Type
+	FunctionName      string
+	ModuleName        string
+	Location          Location
+
+	// Kind indicates whether the value receiver is used by an argument or a hook.
+	Kind DependencyKind
+
+	// ActiveProfiles are the profiles under which the value receiver is incompatible, see
+	// Profiles().
+	ActiveProfiles []string
+}
+
+var _ error = (*IncompatibleReceiverError)(nil)
+
+// Error implements error.Error.
+func (e *IncompatibleReceiverError) Error() string {
+	return fmt.Sprintf("%v; valueReceiverType=%q valueType=%q valueRef=%q functionName=%q%s location=%q%s",
+		ErrIncompatibleValueReceiver, e.ValueReceiverType, e.ValueType, e.ValueRef, e.FunctionName, moduleNameField(e.ModuleName), e.Location,
+		activeProfilesField(e.ActiveProfiles))
+}
+
+// Is is for errors.Is.
+func (e *IncompatibleReceiverError) Is(target error) bool {
+	return target == ErrIncompatibleValueReceiver
+}
+
+// PrivateValueError is returned by Program.Validate()/Program.Run() when a private value used by
+// Argument()/Hook() belongs to another Module. It matches ErrPrivateValue.
+type PrivateValueError struct {
+	ValueRef string
+
+	// ValueModuleName is the name of the Module the value belongs to.
+	ValueModuleName string
+
+	FunctionName string
+	ModuleName   string
+	Location     Location
+
+	// Kind indicates whether the value is referenced by an argument or a hook.
+	Kind DependencyKind
+}
+
+var _ error = (*PrivateValueError)(nil)
+
+// Error implements error.Error.
+func (e *PrivateValueError) Error() string {
+	return fmt.Sprintf("%v; valueRef=%q valueModuleName=%q functionName=%q%s location=%q",
+		ErrPrivateValue, e.ValueRef, e.ValueModuleName, e.FunctionName, moduleNameField(e.ModuleName), e.Location)
+}
+
+// Is is for errors.Is.
+func (e *PrivateValueError) Is(target error) bool {
+	return target == ErrPrivateValue
+}
+
+// ValueNotProducedError is returned by Program.Validate()/Program.Run() of a child Program when a
+// value used by Argument()/Hook() is inherited but has not been produced. It matches
+// ErrValueNotProduced.
+type ValueNotProducedError struct {
+	ValueRef string
+
+	// ProducerName is the name of the DI Function, of an ancestor Program, producing the value.
+	ProducerName string
+
+	FunctionName string
+	ModuleName   string
+	Location     Location
+
+	// Kind indicates whether the value is referenced by an argument or a hook.
+	Kind DependencyKind
+}
+
+var _ error = (*ValueNotProducedError)(nil)
+
+// Error implements error.Error.
+func (e *ValueNotProducedError) Error() string {
+	return fmt.Sprintf("%v; valueRef=%q producerName=%q functionName=%q%s location=%q",
+		ErrValueNotProduced, e.ValueRef, e.ProducerName, e.FunctionName, moduleNameField(e.ModuleName), e.Location)
+}
+
+// Is is for errors.Is.
+func (e *ValueNotProducedError) Is(target error) bool {
+	return target == ErrValueNotProduced
+}
+
+// TransientValueHookedError is returned by Program.Validate()/Program.Run() when a value used by
+// Hook() is produced by a transient DI Function. It matches ErrTransientValueHooked.
+type TransientValueHookedError struct {
+	ValueRef string
+
+	// ProducerName is the name of the transient DI Function producing the value.
+	ProducerName string
+
+	FunctionName string
+	ModuleName   string
+	Location     Location
+}
+
+var _ error = (*TransientValueHookedError)(nil)
+
+// Error implements error.Error.
+func (e *TransientValueHookedError) Error() string {
+	return fmt.Sprintf("%v; valueRef=%q producerName=%q functionName=%q%s location=%q",
+		ErrTransientValueHooked, e.ValueRef, e.ProducerName, e.FunctionName, moduleNameField(e.ModuleName), e.Location)
+}
+
+// Is is for errors.Is.
+func (e *TransientValueHookedError) Is(target error) bool {
+	return target == ErrTransientValueHooked
+}
+
+// TransientValueInheritedError is returned by Program.Validate()/Program.Run() of a child Program
+// when a value used by Argument()/Hook() is inherited from a transient DI Function of an ancestor,
+// see Program.NewScope(). It matches ErrTransientValueInherited.
+type TransientValueInheritedError struct {
+	ValueRef string
+
+	// ProducerName is the name of the transient DI Function producing the value.
+	ProducerName string
+
+	FunctionName string
+	ModuleName   string
+	Location     Location
+
+	// Kind indicates whether the value is referenced by an argument or a hook.
+	Kind DependencyKind
+}
+
+var _ error = (*TransientValueInheritedError)(nil)
+
+// Error implements error.Error.
+func (e *TransientValueInheritedError) Error() string {
+	return fmt.Sprintf("%v; valueRef=%q producerName=%q functionName=%q%s location=%q",
+		ErrTransientValueInherited, e.ValueRef, e.ProducerName, e.FunctionName, moduleNameField(e.ModuleName), e.Location)
+}
+
+// Is is for errors.Is.
+func (e *TransientValueInheritedError) Is(target error) bool {
+	return target == ErrTransientValueInherited
+}
+
+// ProducerDisabledError is returned by Program.Run(), or by Program.Validate()/Program.Run() of a
+// child Program for values inherited, when a value used by Argument() is produced by a DI Function
+// disabled by a condition. It matches ErrProducerDisabled.
+type ProducerDisabledError struct {
+	ValueRef     string
+	ProducerName string
+
+	// ConditionLocation is the location of When()/WhenValue() disabling the producer.
+	ConditionLocation Location
+
+	// ConditionValueRef is the value ref of WhenValue() disabling the producer, or empty for When().
+	ConditionValueRef string
+
+	FunctionName string
+	ModuleName   string
+	Location     Location
+}
+
+var _ error = (*ProducerDisabledError)(nil)
+
+// Error implements error.Error.
+func (e *ProducerDisabledError) Error() string {
+	conditionValueRefField := ""
+	if e.ConditionValueRef != "" {
+		conditionValueRefField = fmt.Sprintf(" conditionValueRef=%q", e.ConditionValueRef)
+	}
+	return fmt.Sprintf("%v; valueRef=%q producerName=%q conditionLocation=%q%s functionName=%q%s location=%q",
+		ErrProducerDisabled, e.ValueRef, e.ProducerName, e.ConditionLocation, conditionValueRefField,
+		e.FunctionName, moduleNameField(e.ModuleName), e.Location)
+}
+
+// Is is for errors.Is.
+func (e *ProducerDisabledError) Is(target error) bool {
+	return target == ErrProducerDisabled
+}
+
+// CircularDependencyError is returned by Program.Validate()/Program.Run() for each dependency cycle
+// detected. It matches ErrCircularDependencies.
+type CircularDependencyError struct {
+	Cycle DependencyCycle
+}
+
+var _ error = (*CircularDependencyError)(nil)
+
+// Error implements error.Error.
+func (e *CircularDependencyError) Error() string {
+	return fmt.Sprintf("%v; path=%q locations=%q", ErrCircularDependencies, e.Cycle, e.Cycle.locations())
+}
+
+// Is is for errors.Is.
+func (e *CircularDependencyError) Is(target error) bool {
+	return target == ErrCircularDependencies
+}
+
+// FunctionCallError is returned by Program.Run() when the body of a DI Function fails.
+type FunctionCallError struct {
+	FunctionName string
+	ModuleName   string
+	Location     Location
+	Err          error
+}
+
+var _ error = (*FunctionCallError)(nil)
+
+// Error implements error.Error.
+func (e *FunctionCallError) Error() string {
+	return fmt.Sprintf("call function; functionName=%q%s location=%q: %v", e.FunctionName, moduleNameField(e.ModuleName), e.Location, e.Err)
+}
+
+// Unwrap returns the error returned by the body.
+func (e *FunctionCallError) Unwrap() error {
+	return e.Err
+}
+
+// HookCallbackError is returned by Program.Run() when the callback of a hook fails.
+type HookCallbackError struct {
+	FunctionName string
+	ModuleName   string
+	ValueRef     string
+	Location     Location
+	Err          error
+}
+
+var _ error = (*HookCallbackError)(nil)
+
+// Error implements error.Error.
+func (e *HookCallbackError) Error() string {
+	return fmt.Sprintf("do callback; functionName=%q%s valueRef=%q location=%q: %v",
+		e.FunctionName, moduleNameField(e.ModuleName), e.ValueRef, e.Location, e.Err)
+}
+
+// Unwrap returns the error returned by the callback.
+func (e *HookCallbackError) Unwrap() error {
+	return e.Err
+}
+
+// moduleNameField returns the field of the module name for error messages, which is omitted
+// for DI Functions added outside Modules.
+func moduleNameField(moduleName string) string {
+	if moduleName == "" {
+		return ""
+	}
+	return fmt.Sprintf(" moduleName=%q", moduleName)
+}
+
+// activeProfilesField returns the field of active profiles for error messages, which is omitted
+// if no profile is active.
+func activeProfilesField(activeProfiles []string) string {
+	if len(activeProfiles) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" activeProfiles=%q", activeProfiles)
+}
@@ -0,0 +1,104 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateValueNameError(t *testing.T) {
+	var p Program
+	var x int
+	p.MustDoNewFunction("F1", Result("x", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("F2", Result("x", &x), Body(func(context.Context) error { return nil }))
+	err := p.Validate()
+	var e *DuplicateValueNameError
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, "x", e.ValueName)
+		assert.Equal(t, "F2", e.FunctionName1)
+		assert.Equal(t, "F1", e.FunctionName2)
+		assert.ErrorIs(t, e, ErrDuplicateValueName)
+	}
+}
+
+func TestValueNotFoundError(t *testing.T) {
+	var p Program
+	var x int
+	p.MustDoNewFunction("F1", Result("USER_NAME", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("F2", Body(func(context.Context) error { return nil }),
+		Hook("USER-NAME", &x, func(context.Context) error { return nil }))
+	err := p.Validate()
+	var e *ValueNotFoundError
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, "USER-NAME", e.ValueRef)
+		assert.Equal(t, "F2", e.FunctionName)
+		assert.Equal(t, DependencyHook, e.Kind)
+		assert.Equal(t, []Suggestion{{ValueName: "USER_NAME", FunctionName: "F1"}}, e.Suggestions)
+		assert.ErrorIs(t, e, ErrValueNotFound)
+	}
+}
+
+func TestIncompatibleReceiverError(t *testing.T) {
+	var p Program
+	var x int
+	var y string
+	p.MustDoNewFunction("F1", Result("x", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("F2", Argument("x", &y), Body(func(context.Context) error { return nil }))
+	err := p.Validate()
+	var e *IncompatibleReceiverError
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, "x", e.ValueRef)
+		assert.Equal(t, reflect.TypeOf(""), e.ValueReceiverType)
+		assert.Equal(t, reflect.TypeOf(0), e.ValueType)
+		assert.Equal(t, "F2", e.FunctionName)
+		assert.Equal(t, DependencyArgument, e.Kind)
+		assert.ErrorIs(t, e, ErrIncompatibleValueReceiver)
+	}
+}
+
+func TestCircularDependencyError(t *testing.T) {
+	var p Program
+	var x, y int
+	p.MustDoNewFunction("F1", Argument("y", &y), Result("x", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("F2", Argument("x", &x), Result("y", &y), Body(func(context.Context) error { return nil }))
+	err := p.Validate()
+	var e *CircularDependencyError
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, `F1@argument:y => F2@argument:x => F1`, e.Cycle.String())
+		assert.ErrorIs(t, e, ErrCircularDependencies)
+	}
+}
+
+func TestFunctionCallError(t *testing.T) {
+	var p Program
+	errFoo := errors.New("foo")
+	p.MustDoNewFunction("F1", Body(func(context.Context) error { return errFoo }))
+	err := p.Run(context.Background())
+	var e *FunctionCallError
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, "F1", e.FunctionName)
+		assert.Equal(t, errFoo, e.Err)
+		assert.ErrorIs(t, e, errFoo)
+	}
+}
+
+func TestHookCallbackError(t *testing.T) {
+	var p Program
+	var x int
+	errFoo := errors.New("foo")
+	p.MustDoNewFunction("F1", Result("x", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("F2", Body(func(context.Context) error { return nil }),
+		Hook("x", &x, func(context.Context) error { return errFoo }))
+	err := p.Run(context.Background())
+	var e *HookCallbackError
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, "F2", e.FunctionName)
+		assert.Equal(t, "x", e.ValueRef)
+		assert.Equal(t, errFoo, e.Err)
+		assert.ErrorIs(t, e, errFoo)
+	}
+}
@@ -0,0 +1,276 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Explanation is the result of Program.Explain().
+type Explanation struct {
+	// ValueName is the name of the value explained, empty if a DI Function is explained.
+	ValueName string
+
+	// Function is the DI Function explained, or the DI Function producing the value explained.
+	Function FunctionRef
+
+	// DirectConsumers are DI Functions referencing the value, or any result of the DI Function,
+	// via arguments.
+	DirectConsumers []Consumer
+
+	// TransitiveConsumers are DI Functions consuming results of direct consumers, directly
+	// or indirectly, sorted by names.
+	TransitiveConsumers []FunctionRef
+
+	// Hooks are hooks attached to the value, or to any result of the DI Function.
+	Hooks []Consumer
+
+	// DependencyChain is the shortest chain from a root to the DI Function, in which each step
+	// references a value produced by the DI Function of the next step, and the last step
+	// references a value produced by the DI Function explained. It is empty if the DI Function
+	// is a root itself, and nil if the DI Function is dead. See Program.Analyze() for roots.
+	DependencyChain []DependencyStep
+}
+
+// FunctionRef refers to a DI Function.
+type FunctionRef struct {
+	Name     string
+	Location Location
+}
+
+// Consumer describes a DI Function referencing a value via an argument or a hook.
+type Consumer struct {
+	FunctionName string
+	Kind         DependencyKind
+	ValueRef     string
+	IsOptional   bool
+
+	// Location is the location of the argument or the hook.
+	Location Location
+}
+
+// Explain tells why a DI Function is called and what depends on a value. The given name is
+// looked up as a value name first and then as a function name, if more than one DI Function
+// have the same name, the DI Function added first is explained. Values which cannot be
+// resolved, and DI Functions overridden or excluded by profiles, are ignored.
+func (p *Program) Explain(valueNameOrFunctionName string) (*Explanation, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_ = p.resolve()
+	var explanation Explanation
+	var resultIndexes []int
+	functionIndex := -1
+	for resultIndex := range p.results {
+		result := &p.results[resultIndex]
+		if result.ValueName == valueNameOrFunctionName && p.isActive(result.FunctionIndex) {
+			explanation.ValueName = result.ValueName
+			resultIndexes = []int{resultIndex}
+			functionIndex = result.FunctionIndex
+			break
+		}
+	}
+	if functionIndex < 0 {
+		for functionIndex2 := range p.functions {
+			function := &p.functions[functionIndex2]
+			if function.Name == valueNameOrFunctionName && p.isActive(functionIndex2) {
+				resultIndexes = function.ResultIndexes
+				functionIndex = functionIndex2
+				break
+			}
+		}
+	}
+	if functionIndex < 0 {
+		return nil, fmt.Errorf("%w; name=%q", ErrNameNotFound, valueNameOrFunctionName)
+	}
+	function := &p.functions[functionIndex]
+	explanation.Function = FunctionRef{function.Name, function.Location}
+	consumers := p.consumers()
+	isDirectConsumer := make(map[int]bool)
+	for _, resultIndex := range resultIndexes {
+		for _, consumer := range consumers[resultIndex] {
+			switch consumer.Kind {
+			case DependencyArgument:
+				explanation.DirectConsumers = append(explanation.DirectConsumers, consumer.Consumer)
+				isDirectConsumer[consumer.FunctionIndex] = true
+			case DependencyHook:
+				explanation.Hooks = append(explanation.Hooks, consumer.Consumer)
+			}
+		}
+	}
+	isVisited := make(map[int]bool, len(isDirectConsumer))
+	var visit func(int)
+	visit = func(functionIndex int) {
+		for _, resultIndex := range p.functions[functionIndex].ResultIndexes {
+			for _, consumer := range consumers[resultIndex] {
+				if consumer.Kind != DependencyArgument || isVisited[consumer.FunctionIndex] {
+					continue
+				}
+				isVisited[consumer.FunctionIndex] = true
+				visit(consumer.FunctionIndex)
+			}
+		}
+	}
+	for consumerFunctionIndex := range isDirectConsumer {
+		visit(consumerFunctionIndex)
+	}
+	for consumerFunctionIndex := range isVisited {
+		if isDirectConsumer[consumerFunctionIndex] || consumerFunctionIndex == functionIndex {
+			continue
+		}
+		consumerFunction := &p.functions[consumerFunctionIndex]
+		explanation.TransitiveConsumers = append(explanation.TransitiveConsumers,
+			FunctionRef{consumerFunction.Name, consumerFunction.Location})
+	}
+	sort.Slice(explanation.TransitiveConsumers, func(i, j int) bool {
+		functionRef1, functionRef2 := &explanation.TransitiveConsumers[i], &explanation.TransitiveConsumers[j]
+		if functionRef1.Name != functionRef2.Name {
+			return functionRef1.Name < functionRef2.Name
+		}
+		return functionRef1.Location.String() < functionRef2.Location.String()
+	})
+	explanation.DependencyChain = p.findDependencyChain(functionIndex, consumers)
+	return &explanation, nil
+}
+
+// ErrNameNotFound is returned by Program.Explain() when neither a value nor a DI Function has
+// the given name.
+var ErrNameNotFound = errors.New("di: name not found")
+
+type consumer struct {
+	Consumer
+	FunctionIndex int
+}
+
+// consumers returns consumers by result indexes.
+func (p *Program) consumers() [][]consumer {
+	consumers := make([][]consumer, len(p.results))
+	for argumentIndex := range p.arguments {
+		argument := &p.arguments[argumentIndex]
+		if argument.ResultIndex < 0 {
+			continue
+		}
+		consumers[argument.ResultIndex] = append(consumers[argument.ResultIndex], consumer{
+			Consumer: Consumer{
+				FunctionName: p.functions[argument.FunctionIndex].Name,
+				Kind:         DependencyArgument,
+				ValueRef:     argument.ValueRef,
+				IsOptional:   argument.IsOptional,
+				Location:     argument.Location,
+			},
+			FunctionIndex: argument.FunctionIndex,
+		})
+	}
+	for resultIndex := range p.results {
+		for _, hookIndex := range p.results[resultIndex].HookIndexes {
+			hook := &p.hooks[hookIndex]
+			consumers[resultIndex] = append(consumers[resultIndex], consumer{
+				Consumer: Consumer{
+					FunctionName: p.functions[hook.FunctionIndex].Name,
+					Kind:         DependencyHook,
+					ValueRef:     hook.ValueRef,
+					Location:     hook.Location,
+				},
+				FunctionIndex: hook.FunctionIndex,
+			})
+		}
+	}
+	return consumers
+}
+
+func (p *Program) findDependencyChain(functionIndex int, consumers [][]consumer) []DependencyStep {
+	if p.functions[functionIndex].isRoot() {
+		return []DependencyStep{}
+	}
+	type visit struct {
+		FunctionIndex int
+		Step          DependencyStep
+		NextVisit     *visit
+	}
+	isVisited := map[int]bool{functionIndex: true}
+	queue := []*visit{{FunctionIndex: functionIndex}}
+	for len(queue) >= 1 {
+		visit1 := queue[0]
+		queue = queue[1:]
+		for _, resultIndex := range p.functions[visit1.FunctionIndex].ResultIndexes {
+			for _, consumer := range consumers[resultIndex] {
+				if isVisited[consumer.FunctionIndex] {
+					continue
+				}
+				isVisited[consumer.FunctionIndex] = true
+				visit2 := &visit{
+					FunctionIndex: consumer.FunctionIndex,
+					Step: DependencyStep{
+						FunctionName: consumer.FunctionName,
+						Kind:         consumer.Kind,
+						ValueRef:     consumer.ValueRef,
+						Location:     consumer.Location,
+					},
+					NextVisit: visit1,
+				}
+				if p.functions[consumer.FunctionIndex].isRoot() {
+					var chain []DependencyStep
+					for visit := visit2; visit.NextVisit != nil; visit = visit.NextVisit {
+						chain = append(chain, visit.Step)
+					}
+					return chain
+				}
+				queue = append(queue, visit2)
+			}
+		}
+	}
+	return nil
+}
+
+// String returns the explanation in text.
+func (e *Explanation) String() string {
+	var builder strings.Builder
+	if e.ValueName == "" {
+		fmt.Fprintf(&builder, "function %s (%v)\n", e.Function.Name, e.Function.Location)
+	} else {
+		fmt.Fprintf(&builder, "value %s\n", e.ValueName)
+		fmt.Fprintf(&builder, "\tproduced by: %s (%v)\n", e.Function.Name, e.Function.Location)
+	}
+	builder.WriteString("\tdirect consumers:")
+	if len(e.DirectConsumers) == 0 {
+		builder.WriteString(" none")
+	}
+	builder.WriteByte('\n')
+	for _, consumer := range e.DirectConsumers {
+		optional := ""
+		if consumer.IsOptional {
+			optional = " (optional)"
+		}
+		fmt.Fprintf(&builder, "\t\t%s@argument:%s%s (%v)\n", consumer.FunctionName, consumer.ValueRef, optional, consumer.Location)
+	}
+	builder.WriteString("\ttransitive consumers:")
+	if len(e.TransitiveConsumers) == 0 {
+		builder.WriteString(" none")
+	}
+	builder.WriteByte('\n')
+	for _, functionRef := range e.TransitiveConsumers {
+		fmt.Fprintf(&builder, "\t\t%s (%v)\n", functionRef.Name, functionRef.Location)
+	}
+	builder.WriteString("\thooks:")
+	if len(e.Hooks) == 0 {
+		builder.WriteString(" none")
+	}
+	builder.WriteByte('\n')
+	for _, hook := range e.Hooks {
+		fmt.Fprintf(&builder, "\t\t%s@hook:%s (%v)\n", hook.FunctionName, hook.ValueRef, hook.Location)
+	}
+	builder.WriteString("\tdependency chain: ")
+	switch {
+	case e.DependencyChain == nil:
+		builder.WriteString("none, required by no root")
+	case len(e.DependencyChain) == 0:
+		builder.WriteString("none, root itself")
+	default:
+		for _, step := range e.DependencyChain {
+			fmt.Fprintf(&builder, "%s@%s:%s => ", step.FunctionName, step.Kind, step.ValueRef)
+		}
+		builder.WriteString(e.Function.Name)
+	}
+	builder.WriteByte('\n')
+	return builder.String()
+}
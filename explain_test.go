@@ -0,0 +1,109 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_Explain(t *testing.T) {
+	var p Program
+	var config, db, repo, service, unused int
+	body := Body(func(context.Context) error { return nil })
+	p.MustDoNewFunction("provideConfig", Result("CONFIG", &config), body)
+	p.MustDoNewFunction("provideDB", Argument("CONFIG", &config), Result("DB", &db), body)
+	p.MustDoNewFunction("provideRepo", Argument("DB", &db), Result("REPO", &repo), body)
+	p.MustDoNewFunction("provideService", Argument("REPO", &repo), OptionalArgument("CONFIG", &config),
+		Result("SERVICE", &service), body)
+	p.MustDoNewFunction("serve", Argument("SERVICE", &service), body)
+	p.MustDoNewFunction("decorateDB", body, Hook("DB", &db, func(context.Context) error { return nil }))
+	p.MustDoNewFunction("provideUnused", Result("UNUSED", &unused), body)
+
+	explanation, err := p.Explain("CONFIG")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `
+value CONFIG
+	produced by: provideConfig (explain_test.go:*)
+	direct consumers:
+		provideDB@argument:CONFIG (explain_test.go:*)
+		provideService@argument:CONFIG (optional) (explain_test.go:*)
+	transitive consumers:
+		provideRepo (explain_test.go:*)
+		serve (explain_test.go:*)
+	hooks: none
+	dependency chain: decorateDB@hook:DB => provideDB@argument:CONFIG => provideConfig
+`[1:], normalizeLocations(explanation.String()))
+
+	explanation, err = p.Explain("provideDB")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "", explanation.ValueName)
+	assert.Equal(t, "provideDB", explanation.Function.Name)
+	if assert.Len(t, explanation.DirectConsumers, 1) {
+		assert.Equal(t, "provideRepo", explanation.DirectConsumers[0].FunctionName)
+	}
+	if assert.Len(t, explanation.Hooks, 1) {
+		assert.Equal(t, "decorateDB", explanation.Hooks[0].FunctionName)
+		assert.Equal(t, DependencyHook, explanation.Hooks[0].Kind)
+	}
+	assert.Equal(t, `decorateDB@hook:DB => provideDB`, formatChain(explanation))
+
+	explanation, err = p.Explain("serve")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []DependencyStep{}, explanation.DependencyChain)
+
+	explanation, err = p.Explain("UNUSED")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Nil(t, explanation.DependencyChain)
+	assert.Contains(t, explanation.String(), "dependency chain: none, required by no root\n")
+
+	_, err = p.Explain("FOO")
+	assert.EqualError(t, err, ErrNameNotFound.Error()+`; name="FOO"`)
+}
+
+func formatChain(explanation *Explanation) string {
+	var s string
+	for _, step := range explanation.DependencyChain {
+		s += step.FunctionName + "@" + string(step.Kind) + ":" + step.ValueRef + " => "
+	}
+	return s + explanation.Function.Name
+}
+
+func TestProgram_Explain_Inactive(t *testing.T) {
+	var p Program
+	var mailer, mailer2 int
+	body := Body(func(context.Context) error { return nil })
+	p.MustDoNewFunction("provideFakeMailer", Result("MAILER", &mailer), body, Profiles("test"))
+	p.MustDoNewFunction("provideSMTPMailer", Result("MAILER", &mailer), body, Profiles("prod"))
+	p.MustDoNewFunction("serve", Argument("MAILER", &mailer2), body)
+	p.RunOptions.ActiveProfiles = []string{"prod"}
+	explanation, err := p.Explain("MAILER")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "provideSMTPMailer", explanation.Function.Name)
+	if assert.Len(t, explanation.DirectConsumers, 1) {
+		assert.Equal(t, "serve", explanation.DirectConsumers[0].FunctionName)
+	}
+
+	p.MustDoOverride("MAILER", "provideLogMailer", Result("MAILER", &mailer), body)
+	explanation, err = p.Explain("MAILER")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "provideLogMailer", explanation.Function.Name)
+	if assert.Len(t, explanation.DirectConsumers, 1) {
+		assert.Equal(t, "serve", explanation.DirectConsumers[0].FunctionName)
+	}
+	_, err = p.Explain("provideSMTPMailer")
+	assert.ErrorIs(t, err, ErrNameNotFound)
+}
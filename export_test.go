@@ -4,10 +4,41 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"time"
 )
 
 type Function = function
 
+func (p *Program) DoNewFunction(functionName string, functionBuilders ...FunctionBuilder) error {
+	return p.doNewFunction(functionName, callerLocation(1), functionBuilders...)
+}
+
+func (p *Program) MustDoNewFunction(functionName string, functionBuilders ...FunctionBuilder) {
+	if err := p.doNewFunction(functionName, callerLocation(1), functionBuilders...); err != nil {
+		panic(fmt.Sprintf("new function: %v", err))
+	}
+}
+
+func (p *Program) DoOverride(valueName string, functionName string, functionBuilders ...FunctionBuilder) error {
+	return p.override(valueName, functionName, callerLocation(1), functionBuilders...)
+}
+
+func (p *Program) MustDoOverride(valueName string, functionName string, functionBuilders ...FunctionBuilder) {
+	if err := p.override(valueName, functionName, callerLocation(1), functionBuilders...); err != nil {
+		panic(fmt.Sprintf("override: %v", err))
+	}
+}
+
+func (p *Program) SetNow(now func() time.Time) {
+	p.now = now
+}
+
+func (f *Factory) PlanCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.inputsKey2Plan)
+}
+
 func (p *Program) Dump(buffer *bytes.Buffer) {
 	for i := range p.functions {
 		function := &p.functions[i]
@@ -0,0 +1,305 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates request-scoped values by running a subgraph of DI Functions against values
+// produced by a Program, see Program.NewFactory().
+type Factory struct {
+	program     *Program
+	register    func(scope *Program)
+	outputNames []string
+
+	mutex          sync.Mutex
+	inputsKey2Plan map[string]*plan
+}
+
+// NewFactory returns a Factory. On each call to Factory.Create(), the given register function
+// adds request-scoped DI Functions into a fresh child Program of the Program, see
+// Program.NewScope(), and the DI Functions needed to produce the given output values, along with
+// the root DI Functions, i.e. those marked by Root() or without results, are called. Output values
+// must not be produced by transient DI Functions, see Transient().
+//
+// Values are resolved and DI Functions are sorted only at the first call to Factory.Create(), the
+// result is reused by subsequent calls, given that the register function adds the same DI Functions
+// each time. The Program must have been run before Factory.Create() is called.
+func (p *Program) NewFactory(register func(scope *Program), outputNames ...string) *Factory {
+	return &Factory{
+		program:        p,
+		register:       register,
+		outputNames:    outputNames,
+		inputsKey2Plan: make(map[string]*plan),
+	}
+}
+
+// Create calls request-scoped DI Functions and returns the output values by names, along with
+// a function to call cleanups of request-scoped DI Functions. The given inputs are provided as
+// values to request-scoped DI Functions.
+//
+// If an error occurs, cleanups of request-scoped DI Functions called are called before Create
+// returns.
+func (f *Factory) Create(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, func(), error) {
+	scope := f.program.NewScope()
+	inputsKey, err := scope.addInputs(inputs, callerLocation(1))
+	if err != nil {
+		return nil, nil, err
+	}
+	f.register(scope)
+	var plan *plan
+	prepare := func() error {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		scope.mutex.Lock()
+		defer scope.mutex.Unlock()
+		plan = f.inputsKey2Plan[inputsKey]
+		if plan != nil && plan.Matches(scope) {
+			plan.Apply(scope)
+			return nil
+		}
+		var err error
+		plan, err = scope.compile(f.outputNames)
+		if err != nil {
+			return err
+		}
+		if _, ok := f.inputsKey2Plan[inputsKey]; !ok {
+			f.inputsKey2Plan[inputsKey] = plan
+		}
+		return nil
+	}
+	if err := scope.run(ctx, prepare); err != nil {
+		scope.Clean()
+		return nil, nil, err
+	}
+	outputs := make(map[string]interface{}, len(f.outputNames))
+	for i, resultIndex := range plan.OutputResultIndexes {
+		outputs[f.outputNames[i]] = scope.results[resultIndex].Value.Interface()
+	}
+	return outputs, scope.Clean, nil
+}
+
+// addInputs adds a DI Function producing the given inputs as values, it returns a key
+// identifying names and types of the inputs.
+func (p *Program) addInputs(inputs map[string]interface{}, location Location) (string, error) {
+	if len(inputs) == 0 {
+		return "", nil
+	}
+	inputNames := make([]string, 0, len(inputs))
+	for inputName := range inputs {
+		inputNames = append(inputNames, inputName)
+	}
+	sort.Strings(inputNames)
+	var functionBuilders []FunctionBuilder
+	inputsKeyParts := make([]string, len(inputNames))
+	for i, inputName := range inputNames {
+		input := inputs[inputName]
+		if input == nil {
+			return "", fmt.Errorf("%w: nil input; valueName=%q", ErrInvalidInput, inputName)
+		}
+		valuePtr := reflect.New(reflect.TypeOf(input))
+		valuePtr.Elem().Set(reflect.ValueOf(input))
+		functionBuilders = append(functionBuilders, result1(inputName, valuePtr.Interface(), false, location))
+		inputsKeyParts[i] = fmt.Sprintf("%s %v", inputName, valuePtr.Elem().Type())
+	}
+	functionBuilders = append(functionBuilders, Body(func(context.Context) error { return nil }))
+	if err := p.doNewFunction(inputFunctionName, location, functionBuilders...); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return strings.Join(inputsKeyParts, ","), nil
+}
+
+const inputFunctionName = "github.com/go-tk/di.(*Factory).Create"
+
+// plan is the result of resolving values and sorting DI Functions for a Factory.
+type plan struct {
+	Arguments             []argumentPlan
+	Results               []resultPlan
+	Hooks                 []hookPlan
+	FunctionNames         []string
+	SortedFunctionIndexes []int
+	OutputResultIndexes   []int
+	ActiveProfiles        []string
+}
+
+type argumentPlan struct {
+	ValueRef          string
+	ValueReceiverType reflect.Type
+	ResultIndex       int
+	ReceiveValueAddr  bool
+	InheritedValue    reflect.Value
+}
+
+type resultPlan struct {
+	ValueName   string
+	ValueType   reflect.Type
+	HookIndexes []int
+}
+
+type hookPlan struct {
+	ValueRef              string
+	ValueReceiverType     reflect.Type
+	ReceiveValueAddr      bool
+	InheritedValue        reflect.Value
+	InheritedProducerName string
+}
+
+// compile resolves values and sorts DI Functions, keeping only the DI Functions needed to
+// produce the given output values and the root DI Functions.
+func (p *Program) compile(outputNames []string) (*plan, error) {
+	if err := p.resolve(); err != nil {
+		return nil, err
+	}
+	if err := p.sortFunctions(); err != nil {
+		return nil, err
+	}
+	valueName2ResultIndex := make(map[string]int, len(p.results))
+	for resultIndex := range p.results {
+		if p.isActive(p.results[resultIndex].FunctionIndex) {
+			valueName2ResultIndex[p.results[resultIndex].ValueName] = resultIndex
+		}
+	}
+	var plan plan
+	isNeeded := make([]bool, len(p.functions))
+	var stack []int
+	for _, outputName := range outputNames {
+		resultIndex, ok := valueName2ResultIndex[outputName]
+		if !ok {
+			return nil, fmt.Errorf("%w: output not found; valueName=%q", ErrValueNotFound, outputName)
+		}
+		if producer := &p.functions[p.results[resultIndex].FunctionIndex]; producer.IsTransient {
+			// No argument consumes the output, so the transient DI Function would never be called.
+			return nil, fmt.Errorf("%w: transient producer; valueName=%q producerName=%q", ErrInvalidOutput, outputName, producer.Name)
+		}
+		plan.OutputResultIndexes = append(plan.OutputResultIndexes, resultIndex)
+		stack = append(stack, p.results[resultIndex].FunctionIndex)
+	}
+	for functionIndex := range p.functions {
+		if p.functions[functionIndex].isRoot() && p.isActive(functionIndex) {
+			stack = append(stack, functionIndex)
+		}
+	}
+	for len(stack) >= 1 {
+		functionIndex := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if isNeeded[functionIndex] {
+			continue
+		}
+		isNeeded[functionIndex] = true
+		for _, dependency := range p.dependencies(&p.functions[functionIndex]) {
+			stack = append(stack, dependency.FunctionIndex)
+		}
+	}
+	sortedFunctionIndexes := p.sortedFunctionIndexes[:0]
+	for _, functionIndex := range p.sortedFunctionIndexes {
+		if isNeeded[functionIndex] {
+			sortedFunctionIndexes = append(sortedFunctionIndexes, functionIndex)
+		}
+	}
+	p.sortedFunctionIndexes = sortedFunctionIndexes
+	for argumentIndex := range p.arguments {
+		argument := &p.arguments[argumentIndex]
+		plan.Arguments = append(plan.Arguments, argumentPlan{
+			ValueRef:          argument.ValueRef,
+			ValueReceiverType: argument.ValueReceiver.Type(),
+			ResultIndex:       argument.ResultIndex,
+			ReceiveValueAddr:  argument.ReceiveValueAddr,
+			InheritedValue:    argument.InheritedValue,
+		})
+	}
+	for resultIndex := range p.results {
+		result := &p.results[resultIndex]
+		plan.Results = append(plan.Results, resultPlan{
+			ValueName:   result.ValueName,
+			ValueType:   result.Value.Type(),
+			HookIndexes: result.HookIndexes,
+		})
+	}
+	for hookIndex := range p.hooks {
+		hook := &p.hooks[hookIndex]
+		plan.Hooks = append(plan.Hooks, hookPlan{
+			ValueRef:              hook.ValueRef,
+			ValueReceiverType:     hook.ValueReceiver.Type(),
+			ReceiveValueAddr:      hook.ReceiveValueAddr,
+			InheritedValue:        hook.InheritedValue,
+			InheritedProducerName: hook.InheritedProducerName,
+		})
+	}
+	for functionIndex := range p.functions {
+		plan.FunctionNames = append(plan.FunctionNames, p.functions[functionIndex].Name)
+	}
+	plan.SortedFunctionIndexes = append(plan.SortedFunctionIndexes, p.sortedFunctionIndexes...)
+	plan.ActiveProfiles = p.activeProfiles
+	return &plan, nil
+}
+
+// Matches checks whether the given Program has the same DI Functions as the Program the plan
+// is compiled for.
+func (pl *plan) Matches(p *Program) bool {
+	if len(p.functions) != len(pl.FunctionNames) || len(p.arguments) != len(pl.Arguments) ||
+		len(p.results) != len(pl.Results) || len(p.hooks) != len(pl.Hooks) ||
+		!equalProfiles(p.RunOptions.ActiveProfiles, pl.ActiveProfiles) {
+		return false
+	}
+	for functionIndex := range p.functions {
+		if p.functions[functionIndex].Name != pl.FunctionNames[functionIndex] {
+			return false
+		}
+	}
+	for argumentIndex := range p.arguments {
+		argument, argumentPlan := &p.arguments[argumentIndex], &pl.Arguments[argumentIndex]
+		if argument.ValueRef != argumentPlan.ValueRef || argument.ValueReceiver.Type() != argumentPlan.ValueReceiverType {
+			return false
+		}
+	}
+	for resultIndex := range p.results {
+		result, resultPlan := &p.results[resultIndex], &pl.Results[resultIndex]
+		if result.ValueName != resultPlan.ValueName || result.Value.Type() != resultPlan.ValueType {
+			return false
+		}
+	}
+	for hookIndex := range p.hooks {
+		hook, hookPlan := &p.hooks[hookIndex], &pl.Hooks[hookIndex]
+		if hook.ValueRef != hookPlan.ValueRef || hook.ValueReceiver.Type() != hookPlan.ValueReceiverType {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply makes the given Program resolved and sorted as planned.
+func (pl *plan) Apply(p *Program) {
+	for argumentIndex := range p.arguments {
+		argument, argumentPlan := &p.arguments[argumentIndex], &pl.Arguments[argumentIndex]
+		argument.ResultIndex = argumentPlan.ResultIndex
+		argument.ReceiveValueAddr = argumentPlan.ReceiveValueAddr
+		argument.InheritedValue = argumentPlan.InheritedValue
+	}
+	for resultIndex := range p.results {
+		p.results[resultIndex].HookIndexes = pl.Results[resultIndex].HookIndexes
+	}
+	for hookIndex := range p.hooks {
+		hook, hookPlan := &p.hooks[hookIndex], &pl.Hooks[hookIndex]
+		hook.ReceiveValueAddr = hookPlan.ReceiveValueAddr
+		hook.InheritedValue = hookPlan.InheritedValue
+		hook.InheritedProducerName = hookPlan.InheritedProducerName
+	}
+	p.isResolved = true
+	p.resolveErr = nil
+	p.activeProfiles = pl.ActiveProfiles
+	p.sortedFunctionIndexes = append(p.sortedFunctionIndexes[:0], pl.SortedFunctionIndexes...)
+}
+
+var (
+	// ErrInvalidInput is returned by Factory.Create() when an invalid input is given.
+	ErrInvalidInput = errors.New("di: invalid input")
+
+	// ErrInvalidOutput is returned by Factory.Create() when an output value is produced by a
+	// transient DI Function, see Transient().
+	ErrInvalidOutput = errors.New("di: invalid output")
+)
@@ -0,0 +1,129 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactory_Create(t *testing.T) {
+	var p Program
+	var db string
+	p.MustDoNewFunction("provideDB", Result("DB", &db), Body(func(context.Context) error {
+		db = "db"
+		return nil
+	}))
+	p.MustRun(context.Background())
+
+	var mutex sync.Mutex
+	var log []string
+	record := func(s string) {
+		mutex.Lock()
+		log = append(log, s)
+		mutex.Unlock()
+	}
+	factory := p.NewFactory(func(scope *Program) {
+		var db, requestID, tx, logger string
+		scope.MustDoNewFunction("provideTx", Argument("DB", &db), Result("TX", &tx), Body(func(context.Context) error {
+			tx = "tx on " + db
+			return nil
+		}), Cleanup(func() { record("rollback " + tx) }))
+		scope.MustDoNewFunction("provideLogger", Argument("REQUEST_ID", &requestID), Result("LOGGER", &logger), Body(func(context.Context) error {
+			logger = "logger for " + requestID
+			return nil
+		}))
+		scope.MustDoNewFunction("audit", Body(func(context.Context) error {
+			record("audit")
+			return nil
+		}))
+		var unused string
+		scope.MustDoNewFunction("provideUnused", Result("UNUSED", &unused), Body(func(context.Context) error {
+			record("unused")
+			return nil
+		}))
+	}, "TX", "LOGGER")
+
+	for i := 0; i < 3; i++ {
+		outputs, cleanup, err := factory.Create(context.Background(), map[string]interface{}{"REQUEST_ID": fmt.Sprint(i)})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, map[string]interface{}{"TX": "tx on db", "LOGGER": fmt.Sprintf("logger for %d", i)}, outputs)
+		cleanup()
+	}
+	assert.Equal(t, []string{
+		"audit", "rollback tx on db",
+		"audit", "rollback tx on db",
+		"audit", "rollback tx on db",
+	}, log)
+	assert.Equal(t, 1, factory.PlanCount())
+
+	_, _, err := factory.Create(context.Background(), nil)
+	assertEqualError(t, err, `di: value not found; valueRef="REQUEST_ID" functionName="provideLogger" location="factory_test.go:*"`)
+	_, _, err = factory.Create(context.Background(), map[string]interface{}{"REQUEST_ID": nil})
+	assert.EqualError(t, err, `di: invalid input: nil input; valueName="REQUEST_ID"`)
+	_, _, err = p.NewFactory(func(*Program) {}, "FOO").Create(context.Background(), nil)
+	assert.EqualError(t, err, `di: value not found: output not found; valueName="FOO"`)
+	_, _, err = p.NewFactory(func(scope *Program) {
+		var buf string
+		scope.MustDoNewFunction("provideBuf", Result("BUF", &buf), Body(func(context.Context) error {
+			buf = "buf"
+			return nil
+		}), Transient())
+	}, "BUF").Create(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrInvalidOutput)
+	assert.EqualError(t, err, `di: invalid output: transient producer; valueName="BUF" producerName="provideBuf"`)
+}
+
+func TestFactory_Create_Error(t *testing.T) {
+	var p Program
+	var log []string
+	factory := p.NewFactory(func(scope *Program) {
+		var x int
+		scope.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }),
+			Cleanup(func() { log = append(log, "cleanup X") }))
+		scope.MustDoNewFunction("useX", Argument("X", &x), Body(func(context.Context) error { return errors.New("oops") }), Root())
+	}, "X")
+	outputs, cleanup, err := factory.Create(context.Background(), nil)
+	assertEqualError(t, err, `call function; functionName="useX" location="factory_test.go:*": oops`)
+	assert.Nil(t, outputs)
+	assert.Nil(t, cleanup)
+	assert.Equal(t, []string{"cleanup X"}, log)
+}
+
+func TestFactory_Create_Concurrency(t *testing.T) {
+	var p Program
+	var db string
+	p.MustDoNewFunction("provideDB", Result("DB", &db), Body(func(context.Context) error {
+		db = "db"
+		return nil
+	}))
+	p.MustRun(context.Background())
+	factory := p.NewFactory(func(scope *Program) {
+		var db, tx string
+		var n int
+		scope.MustDoNewFunction("provideTx", Argument("DB", &db), Argument("N", &n), Result("TX", &tx), Body(func(context.Context) error {
+			tx = fmt.Sprintf("tx%d on %s", n, db)
+			return nil
+		}))
+	}, "TX")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs, cleanup, err := factory.Create(context.Background(), map[string]interface{}{"N": i})
+			if assert.NoError(t, err) {
+				assert.Equal(t, fmt.Sprintf("tx%d on db", i), outputs["TX"])
+				cleanup()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, factory.PlanCount())
+}
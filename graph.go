@@ -0,0 +1,237 @@
+package di
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Graph is a snapshot of DI Functions added into a Program, for inspection.
+type Graph struct {
+	Functions []FunctionInfo
+}
+
+// FunctionInfo describes a DI Function.
+type FunctionInfo struct {
+	Name     string
+	Location Location
+
+	// ModuleName is the name of the Module the DI Function belongs to, or empty if none.
+	ModuleName string
+
+	Arguments  []ArgumentInfo
+	Results    []ResultInfo
+	Hooks      []HookInfo
+	HasCleanup bool
+	IsRoot     bool
+
+	// IsConditional indicates whether the DI Function has conditions, see When().
+	IsConditional bool
+
+	// Profiles are the profiles the DI Function is restricted to, see Profiles().
+	Profiles []string
+	// IsActive indicates whether the DI Function is active under RunOptions.ActiveProfiles,
+	// and not overridden.
+	IsActive bool
+
+	// IsOverridden indicates whether the DI Function is replaced by another one, and
+	// OverriddenIndexes are indexes of DI Functions, in Graph.Functions, replaced by the DI
+	// Function, see Program.Override().
+	IsOverridden      bool
+	OverriddenIndexes []int
+}
+
+// ArgumentInfo describes an argument of a DI Function.
+type ArgumentInfo struct {
+	ValueRef          string
+	ValueReceiverType reflect.Type
+	IsOptional        bool
+	Location          Location
+
+	// ProducerIndex is the index of the DI Function, in Graph.Functions, producing the value
+	// referenced, or -1 if the value cannot be resolved.
+	ProducerIndex int
+
+	// IsPrivate indicates whether the value referenced is private, see PrivateResult().
+	IsPrivate bool
+	// IsInherited indicates whether the value referenced is produced by an ancestor Program,
+	// see Program.NewScope().
+	IsInherited bool
+	// IsCondition indicates whether the argument is specified by WhenValue().
+	IsCondition bool
+}
+
+// ResultInfo describes a result of a DI Function.
+type ResultInfo struct {
+	ValueName string
+	ValueType reflect.Type
+	Location  Location
+
+	// IsPrivate indicates whether the value is private, see PrivateResult().
+	IsPrivate bool
+}
+
+// HookInfo describes a hook of a DI Function.
+type HookInfo struct {
+	ValueRef          string
+	ValueReceiverType reflect.Type
+	Location          Location
+
+	// ProducerIndex is the index of the DI Function, in Graph.Functions, producing the value
+	// referenced, or -1 if the value cannot be resolved.
+	ProducerIndex int
+
+	// IsPrivate indicates whether the value referenced is private, see PrivateResult().
+	IsPrivate bool
+	// IsInherited indicates whether the value referenced is produced by an ancestor Program,
+	// see Program.NewScope().
+	IsInherited bool
+}
+
+// Graph returns a snapshot of DI Functions added into the Program. Values which cannot be
+// resolved are ignored.
+func (p *Program) Graph() Graph {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_ = p.resolve()
+	hookIndex2ResultIndex := make(map[int]int, len(p.hooks))
+	for resultIndex := range p.results {
+		for _, hookIndex := range p.results[resultIndex].HookIndexes {
+			hookIndex2ResultIndex[hookIndex] = resultIndex
+		}
+	}
+	graph := Graph{Functions: make([]FunctionInfo, len(p.functions))}
+	for functionIndex := range p.functions {
+		function := &p.functions[functionIndex]
+		functionInfo := &graph.Functions[functionIndex]
+		functionInfo.Name = function.Name
+		functionInfo.Location = function.Location
+		functionInfo.ModuleName = function.ModuleName
+		for _, argumentIndex := range function.ArgumentIndexes {
+			argument := &p.arguments[argumentIndex]
+			argumentInfo := ArgumentInfo{
+				ValueRef:          argument.ValueRef,
+				ValueReceiverType: argument.ValueReceiver.Type(),
+				IsOptional:        argument.IsOptional,
+				Location:          argument.Location,
+				ProducerIndex:     -1,
+			}
+			if argument.ResultIndex >= 0 {
+				argumentInfo.ProducerIndex = p.results[argument.ResultIndex].FunctionIndex
+				argumentInfo.IsPrivate = p.results[argument.ResultIndex].IsPrivate
+			}
+			argumentInfo.IsInherited = argument.InheritedValue.IsValid()
+			argumentInfo.IsCondition = argument.IsCondition
+			functionInfo.Arguments = append(functionInfo.Arguments, argumentInfo)
+		}
+		for _, resultIndex := range function.ResultIndexes {
+			result := &p.results[resultIndex]
+			functionInfo.Results = append(functionInfo.Results, ResultInfo{
+				ValueName: result.ValueName,
+				ValueType: result.Value.Type(),
+				Location:  result.Location,
+				IsPrivate: result.IsPrivate,
+			})
+		}
+		for _, hookIndex := range function.HookIndexes {
+			hook := &p.hooks[hookIndex]
+			hookInfo := HookInfo{
+				ValueRef:          hook.ValueRef,
+				ValueReceiverType: hook.ValueReceiver.Type(),
+				Location:          hook.Location,
+				ProducerIndex:     -1,
+			}
+			if resultIndex, ok := hookIndex2ResultIndex[hookIndex]; ok {
+				hookInfo.ProducerIndex = p.results[resultIndex].FunctionIndex
+				hookInfo.IsPrivate = p.results[resultIndex].IsPrivate
+			}
+			hookInfo.IsInherited = hook.InheritedValue.IsValid()
+			functionInfo.Hooks = append(functionInfo.Hooks, hookInfo)
+		}
+		functionInfo.HasCleanup = function.Cleanup != nil
+		functionInfo.IsRoot = function.IsRoot
+		functionInfo.IsConditional = len(function.Conditions) >= 1
+		functionInfo.Profiles = function.Profiles
+		functionInfo.IsActive = p.isActive(functionIndex)
+		functionInfo.IsOverridden = function.IsOverridden
+		functionInfo.OverriddenIndexes = function.OverriddenIndexes
+	}
+	return graph
+}
+
+// WriteDOT writes the graph in the DOT language of Graphviz. Edges point from producers to
+// consumers, hooks are drawn with dashed lines and optional arguments with dotted lines. DI
+// Functions of the same Module are grouped into a cluster, and edges of private values are
+// labeled with "(private)". DI Functions overridden are drawn with dashed borders, and linked to
+// DI Functions replacing them with bold lines labeled with "override".
+func (g Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph di {")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+	var moduleNames []string
+	moduleName2FunctionIndexes := make(map[string][]int)
+	for i := range g.Functions {
+		functionInfo := &g.Functions[i]
+		if functionInfo.ModuleName == "" {
+			fmt.Fprintf(bw, "\tf%d [%s];\n", i, nodeAttributes(functionInfo))
+			continue
+		}
+		if _, ok := moduleName2FunctionIndexes[functionInfo.ModuleName]; !ok {
+			moduleNames = append(moduleNames, functionInfo.ModuleName)
+		}
+		moduleName2FunctionIndexes[functionInfo.ModuleName] = append(moduleName2FunctionIndexes[functionInfo.ModuleName], i)
+	}
+	for j, moduleName := range moduleNames {
+		fmt.Fprintf(bw, "\tsubgraph cluster_%d {\n", j)
+		fmt.Fprintf(bw, "\t\tlabel=%q;\n", "module "+moduleName)
+		for _, i := range moduleName2FunctionIndexes[moduleName] {
+			functionInfo := &g.Functions[i]
+			fmt.Fprintf(bw, "\t\tf%d [%s];\n", i, nodeAttributes(functionInfo))
+		}
+		fmt.Fprintln(bw, "\t}")
+	}
+	for i := range g.Functions {
+		functionInfo := &g.Functions[i]
+		for j := range functionInfo.Arguments {
+			argumentInfo := &functionInfo.Arguments[j]
+			if argumentInfo.ProducerIndex < 0 {
+				continue
+			}
+			style := "solid"
+			if argumentInfo.IsOptional {
+				style = "dotted"
+			}
+			fmt.Fprintf(bw, "\tf%d -> f%d [label=%q, tooltip=%q, style=%s];\n", argumentInfo.ProducerIndex, i,
+				edgeLabel(argumentInfo.ValueRef, argumentInfo.IsPrivate), argumentInfo.Location.String(), style)
+		}
+		for j := range functionInfo.Hooks {
+			hookInfo := &functionInfo.Hooks[j]
+			if hookInfo.ProducerIndex < 0 {
+				continue
+			}
+			fmt.Fprintf(bw, "\tf%d -> f%d [label=%q, tooltip=%q, style=dashed];\n", hookInfo.ProducerIndex, i,
+				edgeLabel(hookInfo.ValueRef, hookInfo.IsPrivate), hookInfo.Location.String())
+		}
+		for _, overriddenIndex := range functionInfo.OverriddenIndexes {
+			fmt.Fprintf(bw, "\tf%d -> f%d [label=\"override\", style=bold];\n", overriddenIndex, i)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func nodeAttributes(functionInfo *FunctionInfo) string {
+	attributes := fmt.Sprintf("label=%q", functionInfo.Name+"\n"+functionInfo.Location.String())
+	if functionInfo.IsOverridden {
+		attributes += ", style=dashed"
+	}
+	return attributes
+}
+
+func edgeLabel(valueRef string, isPrivate bool) string {
+	if isPrivate {
+		return valueRef + " (private)"
+	}
+	return valueRef
+}
@@ -0,0 +1,149 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Include copies DI Functions added into the other Program into the Program. The other Program
+// must not have been run, and should not be run afterwards, as copies of DI Functions share
+// bodies, values and value receivers with the originals.
+//
+// If any value name of the other Program is already used in the Program, no DI Function is
+// copied, and a DuplicateValueNameError is returned for each duplicate value name, telling the
+// origins of both results, collected as WiringErrors. Value names used by DI Functions of
+// disjoint profiles are not regarded as duplicates, see Profiles().
+func (p *Program) Include(other *Program) error {
+	other.mutex.Lock()
+	hasRun := !other.runStartTime.IsZero()
+	other.mutex.Unlock()
+	if hasRun {
+		return ErrProgramAlreadyRun
+	}
+	for _, moduleName := range other.moduleNames {
+		for _, moduleName2 := range p.moduleNames {
+			if moduleName == moduleName2 {
+				return fmt.Errorf("%w; moduleName=%q", ErrDuplicateModule, moduleName)
+			}
+		}
+	}
+	valueName2ResultIndexes := make(map[string][]int, len(p.results))
+	for resultIndex := range p.results {
+		valueName := p.results[resultIndex].ValueName
+		valueName2ResultIndexes[valueName] = append(valueName2ResultIndexes[valueName], resultIndex)
+	}
+	var errs WiringErrors
+	for resultIndex := range other.results {
+		result := &other.results[resultIndex]
+		for _, resultIndex2 := range valueName2ResultIndexes[result.ValueName] {
+			result2 := &p.results[resultIndex2]
+			if p.functions[result2.FunctionIndex].IsOverridden || other.functions[result.FunctionIndex].IsOverridden {
+				continue
+			}
+			if !profilesOverlap(p.functions[result2.FunctionIndex].Profiles, other.functions[result.FunctionIndex].Profiles) {
+				continue
+			}
+			errs = append(errs, &DuplicateValueNameError{
+				ValueName:     result.ValueName,
+				FunctionName1: p.functions[result2.FunctionIndex].Name,
+				FunctionName2: other.functions[result.FunctionIndex].Name,
+				Location1:     result2.Location,
+				Location2:     result.Location,
+			})
+			break
+		}
+	}
+	if len(errs) >= 1 {
+		errs.sort()
+		return errs
+	}
+	p.include(other)
+	return nil
+}
+
+func (p *Program) include(other *Program) {
+	// Counts are taken in advance, in case the other Program is the Program itself.
+	functionCount, argumentCount, resultCount, hookCount := len(other.functions), len(other.arguments), len(other.results), len(other.hooks)
+	functionOffset, argumentOffset, resultOffset, hookOffset := len(p.functions), len(p.arguments), len(p.results), len(p.hooks)
+	for functionIndex := 0; functionIndex < functionCount; functionIndex++ {
+		function := other.functions[functionIndex]
+		function.Index += functionOffset
+		function.ArgumentIndexes = offsetIndexes(function.ArgumentIndexes, argumentOffset)
+		function.ResultIndexes = offsetIndexes(function.ResultIndexes, resultOffset)
+		function.HookIndexes = offsetIndexes(function.HookIndexes, hookOffset)
+		function.OverriddenIndexes = offsetIndexes(function.OverriddenIndexes, functionOffset)
+		function.Conditions = offsetConditions(function.Conditions, argumentOffset)
+		p.functions = append(p.functions, function)
+	}
+	for argumentIndex := 0; argumentIndex < argumentCount; argumentIndex++ {
+		argument := other.arguments[argumentIndex]
+		argument.FunctionIndex += functionOffset
+		argument.ResultIndex = -1
+		p.arguments = append(p.arguments, argument)
+	}
+	for resultIndex := 0; resultIndex < resultCount; resultIndex++ {
+		result := other.results[resultIndex]
+		result.FunctionIndex += functionOffset
+		result.HookIndexes = nil
+		p.results = append(p.results, result)
+	}
+	for hookIndex := 0; hookIndex < hookCount; hookIndex++ {
+		hook := other.hooks[hookIndex]
+		hook.FunctionIndex += functionOffset
+		p.hooks = append(p.hooks, hook)
+	}
+	p.moduleNames = append(p.moduleNames, other.moduleNames...)
+	p.isResolved = false
+}
+
+func offsetIndexes(indexes []int, offset int) []int {
+	if indexes == nil {
+		return nil
+	}
+	newIndexes := make([]int, len(indexes))
+	for i, index := range indexes {
+		newIndexes[i] = index + offset
+	}
+	return newIndexes
+}
+
+func offsetConditions(conditions []condition, argumentOffset int) []condition {
+	if conditions == nil {
+		return nil
+	}
+	newConditions := make([]condition, len(conditions))
+	for i, condition := range conditions {
+		if condition.ArgumentIndex >= 0 {
+			condition.ArgumentIndex += argumentOffset
+		}
+		newConditions[i] = condition
+	}
+	return newConditions
+}
+
+// MustInclude likes Include but panics when an error occurs.
+func (p *Program) MustInclude(other *Program) {
+	if err := p.Include(other); err != nil {
+		panic(fmt.Sprintf("include program: %v", err))
+	}
+}
+
+// Merge returns a new Program including DI Functions of the given Programs, in order, see
+// Program.Include(). The given Programs are left untouched, RunOptions of the new Program are
+// those of the first Program.
+func Merge(programs ...*Program) (*Program, error) {
+	var program Program
+	if len(programs) >= 1 {
+		program.RunOptions = programs[0].RunOptions
+	}
+	for _, other := range programs {
+		if err := program.Include(other); err != nil {
+			return nil, err
+		}
+	}
+	return &program, nil
+}
+
+// ErrProgramAlreadyRun is returned by Program.Include()/Merge() when a Program to include has
+// been run.
+var ErrProgramAlreadyRun = errors.New("di: program already run")
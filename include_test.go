@@ -0,0 +1,81 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLibraryProgram() *Program {
+	var p Program
+	var config, db string
+	p.MustDoNewFunction("provideConfig", Result("CONFIG", &config), Body(func(context.Context) error {
+		config = "config"
+		return nil
+	}))
+	p.MustDoNewFunction("provideDB", Argument("CONFIG", &config), Result("DB", &db), Body(func(context.Context) error {
+		db = "db with " + config
+		return nil
+	}))
+	return &p
+}
+
+func TestProgram_Include(t *testing.T) {
+	var p Program
+	var db, db2 string
+	p.MustDoNewFunction("serve", Argument("DB", &db), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("watchDB", Hook("DB", &db2, func(context.Context) error { return nil }), Body(func(context.Context) error { return nil }))
+	library := newLibraryProgram()
+	p.MustInclude(library)
+	p.MustRun(context.Background())
+	assert.Equal(t, "db with config", db)
+	assert.Equal(t, "db with config", db2)
+
+	graph := p.Graph()
+	if assert.Len(t, graph.Functions, 4) {
+		assert.Equal(t, "provideDB", graph.Functions[3].Name)
+		assert.Equal(t, 2, graph.Functions[3].Arguments[0].ProducerIndex)
+		assert.Equal(t, 3, graph.Functions[0].Arguments[0].ProducerIndex)
+		assert.Equal(t, 3, graph.Functions[1].Hooks[0].ProducerIndex)
+	}
+	assert.Len(t, library.Graph().Functions, 2)
+
+	err := p.Include(newLibraryProgram())
+	assertEqualError(t, err, ``+
+		`di: duplicate value name; valueName="CONFIG" functionName1="provideConfig" functionName2="provideConfig" location1="include_test.go:*" location2="include_test.go:*"`+"\n"+
+		`di: duplicate value name; valueName="DB" functionName1="provideDB" functionName2="provideDB" location1="include_test.go:*" location2="include_test.go:*"`)
+	assert.Len(t, p.Graph().Functions, 4)
+
+	var other Program
+	other.MustDoNewFunction("foo", Body(func(context.Context) error { return nil }))
+	other.MustRun(context.Background())
+	assert.ErrorIs(t, p.Include(&other), ErrProgramAlreadyRun)
+	assertPanicsWithValue(t, `include program: di: program already run`, func() { p.MustInclude(&other) })
+
+	var p1, p2 Program
+	p1.MustInstall(NewModule("db"))
+	p2.MustInstall(NewModule("db"))
+	assert.EqualError(t, p1.Include(&p2), `di: duplicate module; moduleName="db"`)
+}
+
+func TestMerge(t *testing.T) {
+	var p1 Program
+	p1.RunOptions.StrictMode = true
+	var db string
+	p1.MustDoNewFunction("serve", Argument("DB", &db), Body(func(context.Context) error { return nil }))
+	p2 := newLibraryProgram()
+	p, err := Merge(&p1, p2)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, p.RunOptions.StrictMode)
+	assert.Len(t, p1.Graph().Functions, 1)
+	assert.Len(t, p2.Graph().Functions, 2)
+	p.MustRun(context.Background())
+	assert.Equal(t, "db with config", db)
+
+	_, err = Merge(newLibraryProgram(), newLibraryProgram())
+	assert.ErrorIs(t, err, ErrDuplicateValueName)
+}
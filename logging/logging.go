@@ -0,0 +1,89 @@
+// Package logging provides an Observer of Program which emits structured log records for
+// startup and shutdown steps.
+package logging
+
+import (
+	"context"
+
+	"github.com/go-tk/di"
+)
+
+// Level is the level of log records, values are the same as levels of log/slog.
+type Level int
+
+// Levels of log records, from the least to the most severe.
+const (
+	LevelDebug = Level(-4)
+	LevelInfo  = Level(0)
+	LevelWarn  = Level(4)
+	LevelError = Level(8)
+)
+
+// Logger is the minimal interface for structured logging, key/value pairs are given in
+// alternating order.
+type Logger interface {
+	Log(ctx context.Context, level Level, msg string, keyValues ...interface{})
+}
+
+// Options represents options for NewObserver().
+type Options struct {
+	// SuccessLevel is the level of records for successful steps, defaults to LevelInfo.
+	// Records for failed steps are always at LevelError.
+	SuccessLevel Level
+}
+
+// NewObserver returns an Observer logging every step of Program.Run()/Program.Clean(),
+// including calls to bodies, hook callbacks and cleanups of DI Functions, with keys
+// "function", "phase", "values", "valueRef", "duration" and "error".
+func NewObserver(logger Logger, options Options) di.Observer {
+	return &observer{
+		logger:  logger,
+		options: options,
+	}
+}
+
+type observer struct {
+	di.NopObserver
+
+	logger  Logger
+	options Options
+}
+
+func (o *observer) OnResolveDone(ctx context.Context, event di.ResolveEvent) {
+	if event.Err == nil {
+		return
+	}
+	o.logger.Log(ctx, LevelError, "failed to resolve", "phase", "resolve", "duration", event.Duration, "error", event.Err)
+}
+
+func (o *observer) OnFunctionEnd(ctx context.Context, event di.FunctionEvent) {
+	keyValues := []interface{}{"function", event.FunctionName, "phase", "body", "values", event.ValueNames, "duration", event.Duration}
+	if event.Err != nil {
+		o.logger.Log(ctx, LevelError, "failed to start "+event.FunctionName, append(keyValues, "error", event.Err)...)
+		return
+	}
+	o.logger.Log(ctx, o.options.SuccessLevel, "started "+event.FunctionName+" in "+event.Duration.String(), keyValues...)
+}
+
+func (o *observer) OnHookEnd(ctx context.Context, event di.HookEvent) {
+	keyValues := []interface{}{"function", event.FunctionName, "phase", "hook", "valueRef", event.ValueRef, "duration", event.Duration}
+	if event.Err != nil {
+		o.logger.Log(ctx, LevelError, "failed to hook "+event.ValueRef, append(keyValues, "error", event.Err)...)
+		return
+	}
+	o.logger.Log(ctx, o.options.SuccessLevel, "hooked "+event.ValueRef+" in "+event.Duration.String(), keyValues...)
+}
+
+func (o *observer) OnCleanupEnd(ctx context.Context, event di.FunctionEvent) {
+	o.logger.Log(ctx, o.options.SuccessLevel, "stopped "+event.FunctionName+" in "+event.Duration.String(),
+		"function", event.FunctionName, "phase", "cleanup", "values", event.ValueNames, "duration", event.Duration)
+}
+
+func (o *observer) OnRunEnd(ctx context.Context, event di.RunEvent) {
+	keyValues := []interface{}{"phase", "run", "duration", event.Duration, "calledFunctionCount", event.CalledFunctionCount}
+	if event.Err != nil {
+		o.logger.Log(ctx, LevelError, "failed to run program", append(keyValues, "error", event.Err)...)
+		return
+	}
+	o.logger.Log(ctx, o.options.SuccessLevel, "ran program in "+event.Duration.String(), keyValues...)
+}
@@ -0,0 +1,46 @@
+package logging_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-tk/di"
+	. "github.com/go-tk/di/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	Records []string
+}
+
+func (rl *recordingLogger) Log(_ context.Context, level Level, msg string, keyValues ...interface{}) {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d %s", level, msg)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if keyValues[i] == "duration" {
+			continue
+		}
+		fmt.Fprintf(&builder, " %v=%v", keyValues[i], keyValues[i+1])
+	}
+	rl.Records = append(rl.Records, builder.String())
+}
+
+func TestNewObserver(t *testing.T) {
+	var program di.Program
+	var x int
+	program.MustNewFunction(di.Result("X", &x), di.Body(func(context.Context) error { return nil }), di.Cleanup(func() {}))
+	program.MustNewFunction(di.Argument("X", &x), di.Body(func(context.Context) error { return errors.New("foo") }))
+	var logger recordingLogger
+	program.RunOptions.Observer = NewObserver(&logger, Options{SuccessLevel: LevelDebug})
+	assert.Error(t, program.Run(context.Background()))
+	program.Clean()
+	if assert.Len(t, logger.Records, 4) {
+		assert.Regexp(t, `^-4 started github.com/go-tk/di/logging_test.TestNewObserver in \S+ function=github.com/go-tk/di/logging_test.TestNewObserver phase=body values=\[X\]$`, logger.Records[0])
+		assert.Regexp(t, `^8 failed to start github.com/go-tk/di/logging_test.TestNewObserver function=\S+ phase=body values=\[\] error=foo$`, logger.Records[1])
+		assert.Regexp(t, `^8 failed to run program phase=run calledFunctionCount=1 error=call function; .*: foo$`, logger.Records[2])
+		assert.Regexp(t, `^-4 stopped github.com/go-tk/di/logging_test.TestNewObserver in \S+ function=\S+ phase=cleanup values=\[X\]$`, logger.Records[3])
+	}
+}
@@ -0,0 +1,21 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger adapts a *slog.Logger to Logger.
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (sl slogLogger) Log(ctx context.Context, level Level, msg string, keyValues ...interface{}) {
+	sl.logger.Log(ctx, slog.Level(level), msg, keyValues...)
+}
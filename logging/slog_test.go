@@ -0,0 +1,33 @@
+//go:build go1.21
+
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/go-tk/di"
+	. "github.com/go-tk/di/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == "duration" {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	var program di.Program
+	program.MustNewFunction(di.Body(func(context.Context) error { return nil }))
+	program.RunOptions.Observer = NewObserver(SlogLogger(slog.New(handler)), Options{SuccessLevel: LevelWarn})
+	program.MustRun(context.Background())
+	assert.Regexp(t, `^level=WARN msg="started github.com/go-tk/di/logging_test.TestSlogLogger in \S+" function=github.com/go-tk/di/logging_test.TestSlogLogger phase=body values=\[\]
+level=WARN msg="ran program in \S+" phase=run calledFunctionCount=1
+$`, buffer.String())
+}
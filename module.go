@@ -0,0 +1,108 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Module is a named set of DI Functions, see Program.Install().
+type Module struct {
+	name          string
+	registrations []func(program *Program)
+}
+
+// NewModule returns a Module with the given name and registrations, each registration adds
+// DI Functions into the Program given, by convention it is a function like
+// "Register(program *di.Program)".
+func NewModule(name string, registrations ...func(program *Program)) *Module {
+	return &Module{
+		name:          name,
+		registrations: registrations,
+	}
+}
+
+// Name returns the name of the Module.
+func (m *Module) Name() string { return m.name }
+
+// Install adds DI Functions of the given Modules into the Program. A Module can be installed
+// only once.
+//
+// Value names used by Result() in a Module are qualified with the name of the Module, e.g.
+// value name "POOL" in Module "db" becomes "db.POOL". Value refs used by Argument()/Hook() in
+// a Module are looked up as qualified value names first and then as they are, so values of the
+// same Module can be referenced without qualification, while values of other Modules are
+// referenced as qualified value names.
+func (p *Program) Install(modules ...*Module) error {
+	for _, module := range modules {
+		if err := p.install(module); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Program) install(module *Module) error {
+	if module.name == "" {
+		return fmt.Errorf("%w: empty module name", ErrInvalidModule)
+	}
+	if strings.Contains(module.name, ".") {
+		return fmt.Errorf("%w: dot in module name; moduleName=%q", ErrInvalidModule, module.name)
+	}
+	for _, moduleName := range p.moduleNames {
+		if moduleName == module.name {
+			return fmt.Errorf("%w; moduleName=%q", ErrDuplicateModule, module.name)
+		}
+	}
+	p.moduleNames = append(p.moduleNames, module.name)
+	currentModuleName := p.currentModuleName
+	p.currentModuleName = module.name
+	defer func() { p.currentModuleName = currentModuleName }()
+	for _, registration := range module.registrations {
+		registration(p)
+	}
+	return nil
+}
+
+// MustInstall likes Install but panics when an error occurs.
+func (p *Program) MustInstall(modules ...*Module) {
+	if err := p.Install(modules...); err != nil {
+		panic(fmt.Sprintf("install modules: %v", err))
+	}
+}
+
+func qualifyValueName(moduleName string, valueName string) string {
+	if moduleName == "" {
+		return valueName
+	}
+	return moduleName + "." + valueName
+}
+
+func (p *Program) lookUpValue(valueRef string, moduleName string, valueName2ResultIndex map[string]int) (int, bool) {
+	if moduleName != "" {
+		if resultIndex, ok := valueName2ResultIndex[qualifyValueName(moduleName, valueRef)]; ok {
+			return resultIndex, true
+		}
+	}
+	resultIndex, ok := valueName2ResultIndex[valueRef]
+	return resultIndex, ok
+}
+
+// isVisible checks whether the value of the given result can be referenced by DI Functions in
+// the given Module.
+func (p *Program) isVisible(resultIndex int, moduleName string) bool {
+	result := &p.results[resultIndex]
+	return !result.IsPrivate || p.functions[result.FunctionIndex].ModuleName == moduleName
+}
+
+var (
+	// ErrInvalidModule is returned by Program.Install() when a Module is invalid.
+	ErrInvalidModule = errors.New("di: invalid module")
+
+	// ErrDuplicateModule is returned by Program.Install() when a Module has been installed.
+	ErrDuplicateModule = errors.New("di: duplicate module")
+
+	// ErrPrivateValue is returned by Program.Validate()/Program.Run() when a private value used
+	// by Argument()/Hook() belongs to another Module.
+	ErrPrivateValue = errors.New("di: private value")
+)
@@ -0,0 +1,145 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_Install(t *testing.T) {
+	var p Program
+	var dbPool, cachePool, dbUser, dbPool2, cachePool2 string
+	db := NewModule("db", func(p *Program) {
+		p.MustDoNewFunction("provideDBPool", Result("POOL", &dbPool), Body(func(context.Context) error {
+			dbPool = "db pool"
+			return nil
+		}))
+		p.MustDoNewFunction("provideDBUser", Argument("POOL", &dbUser), Body(func(context.Context) error { return nil }))
+	})
+	cache := NewModule("cache", func(p *Program) {
+		p.MustDoNewFunction("provideCachePool", Result("POOL", &cachePool), Body(func(context.Context) error {
+			cachePool = "cache pool"
+			return nil
+		}))
+	})
+	assert.Equal(t, "db", db.Name())
+	p.MustInstall(db, cache)
+	p.MustDoNewFunction("serve", Argument("db.POOL", &dbPool2), Argument("cache.POOL", &cachePool2),
+		Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+	assert.Equal(t, "db pool", dbUser)
+	assert.Equal(t, "db pool", dbPool2)
+	assert.Equal(t, "cache pool", cachePool2)
+
+	graph := p.Graph()
+	assert.Equal(t, "db", graph.Functions[0].ModuleName)
+	assert.Equal(t, "db.POOL", graph.Functions[0].Results[0].ValueName)
+	assert.Equal(t, "cache", graph.Functions[2].ModuleName)
+	assert.Equal(t, "", graph.Functions[3].ModuleName)
+	var builder strings.Builder
+	if !assert.NoError(t, graph.WriteDOT(&builder)) {
+		t.FailNow()
+	}
+	assert.Equal(t, `
+digraph di {
+	node [shape=box];
+	f3 [label="serve\nmodule_test.go:*"];
+	subgraph cluster_0 {
+		label="module db";
+		f0 [label="provideDBPool\nmodule_test.go:*"];
+		f1 [label="provideDBUser\nmodule_test.go:*"];
+	}
+	subgraph cluster_1 {
+		label="module cache";
+		f2 [label="provideCachePool\nmodule_test.go:*"];
+	}
+	f0 -> f1 [label="POOL", tooltip="module_test.go:*", style=solid];
+	f0 -> f3 [label="db.POOL", tooltip="module_test.go:*", style=solid];
+	f2 -> f3 [label="cache.POOL", tooltip="module_test.go:*", style=solid];
+}
+`[1:], normalizeLocations(builder.String()))
+}
+
+func TestProgram_Install_Errors(t *testing.T) {
+	var p Program
+	err := p.Install(NewModule("db"), NewModule("db"))
+	assert.ErrorIs(t, err, ErrDuplicateModule)
+	assert.EqualError(t, err, `di: duplicate module; moduleName="db"`)
+	err = p.Install(NewModule(""))
+	assert.EqualError(t, err, `di: invalid module: empty module name`)
+	err = p.Install(NewModule("a.b"))
+	assert.EqualError(t, err, `di: invalid module: dot in module name; moduleName="a.b"`)
+	assertPanicsWithValue(t, `install modules: di: duplicate module; moduleName="db"`, func() {
+		p.MustInstall(NewModule("db"))
+	})
+
+	p = Program{}
+	var x int
+	p.MustInstall(NewModule("db", func(p *Program) {
+		p.MustDoNewFunction("provideDB", Result("POOL", &x), Body(func(context.Context) error { return nil }))
+		p.MustDoNewFunction("migrateDB", Argument("SCHEMA", &x), Body(func(context.Context) error { return nil }))
+	}))
+	p.MustDoNewFunction("serve", Argument("POOL", &x), Body(func(context.Context) error { return nil }))
+	assertEqualError(t, p.Validate(), ``+
+		`di: value not found; valueRef="POOL" functionName="serve" location="module_test.go:*" didYouMean="db.POOL (provideDB)"`+"\n"+
+		`di: value not found; valueRef="SCHEMA" functionName="migrateDB" moduleName="db" location="module_test.go:*"`)
+
+	p = Program{}
+	p.MustInstall(NewModule("db", func(p *Program) {
+		p.MustDoNewFunction("provideDB", Body(func(context.Context) error { return errors.New("oops") }))
+	}))
+	assertEqualError(t, p.Run(context.Background()),
+		`call function; functionName="provideDB" moduleName="db" location="module_test.go:*": oops`)
+}
+
+func TestPrivateResult(t *testing.T) {
+	var p Program
+	var config, dsn, pool string
+	p.MustInstall(NewModule("db", func(p *Program) {
+		p.MustDoNewFunction("provideConfig", PrivateResult("CONFIG", &config), Body(func(context.Context) error {
+			config = "config"
+			return nil
+		}))
+		p.MustDoNewFunction("providePool", Argument("CONFIG", &dsn), Result("POOL", &pool), Body(func(context.Context) error {
+			pool = "pool of " + dsn
+			return nil
+		}))
+	}))
+	p.MustDoNewFunction("serve", Argument("db.POOL", &pool), Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+	assert.Equal(t, "pool of config", pool)
+
+	graph := p.Graph()
+	assert.True(t, graph.Functions[0].Results[0].IsPrivate)
+	assert.True(t, graph.Functions[1].Arguments[0].IsPrivate)
+	assert.False(t, graph.Functions[1].Results[0].IsPrivate)
+	var builder strings.Builder
+	if !assert.NoError(t, graph.WriteDOT(&builder)) {
+		t.FailNow()
+	}
+	assert.Contains(t, builder.String(), `f0 -> f1 [label="CONFIG (private)"`)
+
+	var x string
+	p.MustDoNewFunction("hack", Argument("db.CONFIG", &x), Hook("db.CONFIG", &x, func(context.Context) error { return nil }),
+		Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("typo", Argument("CONFIG", &x), Body(func(context.Context) error { return nil }))
+	err := p.Validate()
+	assert.ErrorIs(t, err, ErrPrivateValue)
+	var privateValueError *PrivateValueError
+	if assert.ErrorAs(t, err, &privateValueError) {
+		assert.Equal(t, "db", privateValueError.ValueModuleName)
+	}
+	assertEqualError(t, err, ``+
+		`di: private value; valueRef="db.CONFIG" valueModuleName="db" functionName="hack" location="module_test.go:*"`+"\n"+
+		`di: private value; valueRef="db.CONFIG" valueModuleName="db" functionName="hack" location="module_test.go:*"`+"\n"+
+		`di: value not found; valueRef="CONFIG" functionName="typo" location="module_test.go:*"`)
+
+	p = Program{}
+	err = p.NewFunction(PrivateResult("CONFIG", &x), Body(func(context.Context) error { return nil }))
+	assert.ErrorIs(t, err, ErrInvalidResult)
+	assert.Contains(t, err.Error(), "di: invalid result: private result outside module;")
+}
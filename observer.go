@@ -0,0 +1,176 @@
+package di
+
+import (
+	"context"
+	"time"
+)
+
+// Observer observes lifecycle events of Program.Run()/Program.Clean(). Methods are called
+// synchronously, with the context given to Program.Run(), or context.Background() for
+// Program.Clean(), which carries the current span if RunOptions.Tracer is set.
+type Observer interface {
+	// OnResolveDone is called when DI Functions have been validated.
+	OnResolveDone(ctx context.Context, event ResolveEvent)
+
+	// OnFunctionStart is called before the body of a DI Function is called.
+	OnFunctionStart(ctx context.Context, event FunctionEvent)
+
+	// OnFunctionEnd is called after the body of a DI Function is called.
+	OnFunctionEnd(ctx context.Context, event FunctionEvent)
+
+	// OnHookStart is called before the callback of a hook is called.
+	OnHookStart(ctx context.Context, event HookEvent)
+
+	// OnHookEnd is called after the callback of a hook is called.
+	OnHookEnd(ctx context.Context, event HookEvent)
+
+	// OnCleanupStart is called before the cleanup of a DI Function is called.
+	OnCleanupStart(ctx context.Context, event FunctionEvent)
+
+	// OnCleanupEnd is called after the cleanup of a DI Function is called.
+	OnCleanupEnd(ctx context.Context, event FunctionEvent)
+
+	// OnRunEnd is called when Program.Run() returns.
+	OnRunEnd(ctx context.Context, event RunEvent)
+}
+
+// ResolveEvent is the event of validating DI Functions.
+type ResolveEvent struct {
+	StartTime time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// FunctionEvent is the event of calling the body or the cleanup of a DI Function.
+// Duration and Err are set for end events only.
+type FunctionEvent struct {
+	FunctionName string
+	Location     Location
+
+	// ValueRefs are value refs of the arguments of the DI Function.
+	ValueRefs []string
+
+	// ValueNames are value names of the results of the DI Function.
+	ValueNames []string
+
+	StartTime time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// HookEvent is the event of calling the callback of a hook.
+// Duration and Err are set for end events only.
+type HookEvent struct {
+	FunctionName string
+	ValueRef     string
+	Location     Location
+
+	// ProducerName is the name of the DI Function producing the value.
+	ProducerName string
+
+	StartTime time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// RunEvent is the event of Program.Run().
+type RunEvent struct {
+	StartTime           time.Time
+	Duration            time.Duration
+	CalledFunctionCount int
+	Err                 error
+}
+
+// NopObserver is an Observer doing nothing, it can be embedded into a struct to implement
+// only part of Observer.
+type NopObserver struct{}
+
+var _ Observer = NopObserver{}
+
+// OnResolveDone implements Observer.OnResolveDone.
+func (NopObserver) OnResolveDone(context.Context, ResolveEvent) {}
+
+// OnFunctionStart implements Observer.OnFunctionStart.
+func (NopObserver) OnFunctionStart(context.Context, FunctionEvent) {}
+
+// OnFunctionEnd implements Observer.OnFunctionEnd.
+func (NopObserver) OnFunctionEnd(context.Context, FunctionEvent) {}
+
+// OnHookStart implements Observer.OnHookStart.
+func (NopObserver) OnHookStart(context.Context, HookEvent) {}
+
+// OnHookEnd implements Observer.OnHookEnd.
+func (NopObserver) OnHookEnd(context.Context, HookEvent) {}
+
+// OnCleanupStart implements Observer.OnCleanupStart.
+func (NopObserver) OnCleanupStart(context.Context, FunctionEvent) {}
+
+// OnCleanupEnd implements Observer.OnCleanupEnd.
+func (NopObserver) OnCleanupEnd(context.Context, FunctionEvent) {}
+
+// OnRunEnd implements Observer.OnRunEnd.
+func (NopObserver) OnRunEnd(context.Context, RunEvent) {}
+
+// ComposeObservers returns an Observer notifying the given Observers in order.
+func ComposeObservers(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+type multiObserver []Observer
+
+var _ Observer = multiObserver(nil)
+
+func (mo multiObserver) OnResolveDone(ctx context.Context, event ResolveEvent) {
+	for _, o := range mo {
+		o.OnResolveDone(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnFunctionStart(ctx context.Context, event FunctionEvent) {
+	for _, o := range mo {
+		o.OnFunctionStart(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnFunctionEnd(ctx context.Context, event FunctionEvent) {
+	for _, o := range mo {
+		o.OnFunctionEnd(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnHookStart(ctx context.Context, event HookEvent) {
+	for _, o := range mo {
+		o.OnHookStart(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnHookEnd(ctx context.Context, event HookEvent) {
+	for _, o := range mo {
+		o.OnHookEnd(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnCleanupStart(ctx context.Context, event FunctionEvent) {
+	for _, o := range mo {
+		o.OnCleanupStart(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnCleanupEnd(ctx context.Context, event FunctionEvent) {
+	for _, o := range mo {
+		o.OnCleanupEnd(ctx, event)
+	}
+}
+
+func (mo multiObserver) OnRunEnd(ctx context.Context, event RunEvent) {
+	for _, o := range mo {
+		o.OnRunEnd(ctx, event)
+	}
+}
+
+func (p *Program) observer() Observer {
+	if p.RunOptions.Observer == nil {
+		return NopObserver{}
+	}
+	return p.RunOptions.Observer
+}
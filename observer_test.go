@@ -0,0 +1,93 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	Name   string
+	Events []string
+}
+
+var _ Observer = (*recordingObserver)(nil)
+
+func (ro *recordingObserver) record(format string, args ...interface{}) {
+	ro.Events = append(ro.Events, ro.Name+":"+fmt.Sprintf(format, args...))
+}
+
+func (ro *recordingObserver) OnResolveDone(_ context.Context, event ResolveEvent) {
+	ro.record("resolve done err=%v", event.Err)
+}
+
+func (ro *recordingObserver) OnFunctionStart(_ context.Context, event FunctionEvent) {
+	ro.record("function start %s refs=%v names=%v", event.FunctionName, event.ValueRefs, event.ValueNames)
+}
+
+func (ro *recordingObserver) OnFunctionEnd(_ context.Context, event FunctionEvent) {
+	ro.record("function end %s duration=%v err=%v", event.FunctionName, event.Duration, event.Err)
+}
+
+func (ro *recordingObserver) OnHookStart(_ context.Context, event HookEvent) {
+	ro.record("hook start %s ref=%s producer=%s", event.FunctionName, event.ValueRef, event.ProducerName)
+}
+
+func (ro *recordingObserver) OnHookEnd(_ context.Context, event HookEvent) {
+	ro.record("hook end %s duration=%v err=%v", event.FunctionName, event.Duration, event.Err)
+}
+
+func (ro *recordingObserver) OnCleanupStart(_ context.Context, event FunctionEvent) {
+	ro.record("cleanup start %s", event.FunctionName)
+}
+
+func (ro *recordingObserver) OnCleanupEnd(_ context.Context, event FunctionEvent) {
+	ro.record("cleanup end %s duration=%v", event.FunctionName, event.Duration)
+}
+
+func (ro *recordingObserver) OnRunEnd(_ context.Context, event RunEvent) {
+	ro.record("run end count=%d duration=%v err=%v", event.CalledFunctionCount, event.Duration, event.Err)
+}
+
+func TestObserver(t *testing.T) {
+	var p Program
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.SetNow(func() time.Time { return now })
+	sleep := func(d time.Duration, err error) func(context.Context) error {
+		return func(context.Context) error {
+			now = now.Add(d)
+			return err
+		}
+	}
+	var x int
+	p.MustDoNewFunction("F1", Result("x", &x), Body(sleep(time.Second, nil)), Cleanup(func() { now = now.Add(time.Millisecond) }))
+	p.MustDoNewFunction("F2", Argument("x", &x), Body(sleep(time.Second, errors.New("foo"))))
+	p.MustDoNewFunction("F3", Body(sleep(0, nil)), Hook("x", &x, sleep(2*time.Second, nil)))
+	o1 := recordingObserver{Name: "o1"}
+	o2 := recordingObserver{Name: "o2"}
+	p.RunOptions.Observer = ComposeObservers(&o1, NopObserver{}, &o2)
+	err := p.Run(context.Background())
+	assert.Error(t, err)
+	p.Clean()
+	assert.Equal(t, `
+o1:resolve done err=<nil>
+o1:function start F3 refs=[] names=[]
+o1:function end F3 duration=0s err=<nil>
+o1:function start F1 refs=[] names=[x]
+o1:function end F1 duration=1s err=<nil>
+o1:hook start F3 ref=x producer=F1
+o1:hook end F3 duration=2s err=<nil>
+o1:function start F2 refs=[x] names=[]
+o1:function end F2 duration=1s err=foo
+o1:run end count=2 duration=4s err=call function; functionName="F2" location="observer_test.go:*": foo
+o1:cleanup start F1
+o1:cleanup end F1 duration=1ms
+`[1:], normalizeLocations(strings.Join(o1.Events, "\n")+"\n"))
+	assert.Equal(t, len(o1.Events), len(o2.Events))
+}
@@ -0,0 +1,93 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Override adds a DI Function into the Program replacing the DI Function producing the given
+// value, so that a provider can be swapped, e.g. for tests, without editing the registration
+// code. The new DI Function belongs to the same Module as the DI Function replaced, if any.
+//
+// The DI Function replaced is excluded from the Program as a whole, as if it had never been
+// added, but remains visible in Program.Graph(). Therefore the new DI Function must produce
+// not only the given value but also the other values of the DI Function replaced, with the
+// same types.
+func (p *Program) Override(valueName string, functionBuilders ...FunctionBuilder) error {
+	pc, file, line, _ := runtime.Caller(1)
+	functionName := runtime.FuncForPC(pc).Name()
+	return p.override(valueName, functionName, Location{file, line}, functionBuilders...)
+}
+
+// MustOverride likes Override but panics when an error occurs.
+func (p *Program) MustOverride(valueName string, functionBuilders ...FunctionBuilder) {
+	pc, file, line, _ := runtime.Caller(1)
+	functionName := runtime.FuncForPC(pc).Name()
+	if err := p.override(valueName, functionName, Location{file, line}, functionBuilders...); err != nil {
+		panic(fmt.Sprintf("override: %v", err))
+	}
+}
+
+func (p *Program) override(valueName string, functionName string, location Location, functionBuilders ...FunctionBuilder) (returnedErr error) {
+	var resultIndexes []int
+	for resultIndex := range p.results {
+		result := &p.results[resultIndex]
+		if result.ValueName == valueName && !p.functions[result.FunctionIndex].IsOverridden {
+			resultIndexes = append(resultIndexes, resultIndex)
+		}
+	}
+	if len(resultIndexes) == 0 {
+		return fmt.Errorf("%w: value not found; valueName=%q functionName=%q location=%q",
+			ErrInvalidOverride, valueName, functionName, location)
+	}
+	functionCount, argumentCount, resultCount, hookCount := len(p.functions), len(p.arguments), len(p.results), len(p.hooks)
+	defer func() {
+		if returnedErr != nil {
+			p.functions = p.functions[:functionCount]
+			p.arguments = p.arguments[:argumentCount]
+			p.results = p.results[:resultCount]
+			p.hooks = p.hooks[:hookCount]
+		}
+	}()
+	currentModuleName := p.currentModuleName
+	p.currentModuleName = p.functions[p.results[resultIndexes[0]].FunctionIndex].ModuleName
+	err := p.doNewFunction(functionName, location, functionBuilders...)
+	p.currentModuleName = currentModuleName
+	if err != nil {
+		return err
+	}
+	function := &p.functions[functionCount]
+	valueName2NewResultIndex := make(map[string]int, len(function.ResultIndexes))
+	for _, resultIndex := range function.ResultIndexes {
+		valueName2NewResultIndex[p.results[resultIndex].ValueName] = resultIndex
+	}
+	for _, resultIndex := range resultIndexes {
+		overriddenFunction := &p.functions[p.results[resultIndex].FunctionIndex]
+		// The DI Function replaced is excluded as a whole, so are its other results.
+		for _, overriddenResultIndex := range overriddenFunction.ResultIndexes {
+			overriddenResult := &p.results[overriddenResultIndex]
+			newResultIndex, ok := valueName2NewResultIndex[overriddenResult.ValueName]
+			if !ok {
+				return fmt.Errorf("%w: value not produced; valueName=%q overriddenFunctionName=%q functionName=%q location=%q",
+					ErrInvalidOverride, overriddenResult.ValueName, overriddenFunction.Name, functionName, location)
+			}
+			newValueType, valueType := p.results[newResultIndex].Value.Type(), overriddenResult.Value.Type()
+			if newValueType != valueType {
+				return fmt.Errorf("%w: value type mismatch; valueName=%q valueType=%q overriddenValueType=%q overriddenFunctionName=%q functionName=%q location=%q",
+					ErrInvalidOverride, overriddenResult.ValueName, newValueType, valueType, overriddenFunction.Name, functionName, location)
+			}
+		}
+	}
+	for _, resultIndex := range resultIndexes {
+		overriddenFunction := &p.functions[p.results[resultIndex].FunctionIndex]
+		overriddenFunction.IsOverridden = true
+		function.OverriddenIndexes = append(function.OverriddenIndexes, overriddenFunction.Index)
+	}
+	return nil
+}
+
+// ErrInvalidOverride is returned by Program.Override() when the value to override does not
+// exist, or any value of the DI Function replaced is not produced with the same type by the
+// new DI Function.
+var ErrInvalidOverride = errors.New("di: invalid override")
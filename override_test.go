@@ -0,0 +1,129 @@
+package di_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_Override(t *testing.T) {
+	var p Program
+	var log []string
+	var mailer, mailer2 string
+	p.MustDoNewFunction("provideMailer", Result("MAILER", &mailer), Body(func(context.Context) error {
+		log = append(log, "new smtp mailer")
+		mailer = "smtp"
+		return nil
+	}), Cleanup(func() { log = append(log, "close smtp mailer") }))
+	p.MustDoNewFunction("serve", Argument("MAILER", &mailer2), Body(func(context.Context) error { return nil }))
+	var fakeMailer string
+	p.MustDoOverride("MAILER", "provideFakeMailer", Result("MAILER", &fakeMailer), Body(func(context.Context) error {
+		log = append(log, "new fake mailer")
+		fakeMailer = "fake"
+		return nil
+	}))
+	p.MustRun(context.Background())
+	p.Clean()
+	assert.Equal(t, "fake", mailer2)
+	assert.Equal(t, []string{"new fake mailer"}, log)
+
+	graph := p.Graph()
+	if assert.Len(t, graph.Functions, 3) {
+		assert.True(t, graph.Functions[0].IsOverridden)
+		assert.False(t, graph.Functions[0].IsActive)
+		assert.Equal(t, []int{0}, graph.Functions[2].OverriddenIndexes)
+		assert.Equal(t, 2, graph.Functions[1].Arguments[0].ProducerIndex)
+	}
+	var builder strings.Builder
+	if !assert.NoError(t, graph.WriteDOT(&builder)) {
+		t.FailNow()
+	}
+	assert.Equal(t, `
+digraph di {
+	node [shape=box];
+	f0 [label="provideMailer\noverride_test.go:*", style=dashed];
+	f1 [label="serve\noverride_test.go:*"];
+	f2 [label="provideFakeMailer\noverride_test.go:*"];
+	f2 -> f1 [label="MAILER", tooltip="override_test.go:*", style=solid];
+	f0 -> f2 [label="override", style=bold];
+}
+`[1:], normalizeLocations(builder.String()))
+}
+
+func TestProgram_Override_Module(t *testing.T) {
+	var p Program
+	var db, db2 string
+	p.MustInstall(NewModule("storage", func(p *Program) {
+		p.MustDoNewFunction("provideDB", Result("DB", &db), Body(func(context.Context) error {
+			db = "postgres"
+			return nil
+		}))
+	}))
+	p.MustDoNewFunction("serve", Argument("storage.DB", &db2), Body(func(context.Context) error { return nil }))
+	var memDB string
+	p.MustDoOverride("storage.DB", "provideMemDB", Result("DB", &memDB), Body(func(context.Context) error {
+		memDB = "memory"
+		return nil
+	}))
+	p.MustRun(context.Background())
+	assert.Equal(t, "memory", db2)
+	assert.Equal(t, "storage", p.Graph().Functions[2].ModuleName)
+
+	// Overrides can be overridden.
+	p = Program{}
+	var x, y int
+	p.MustDoNewFunction("provideX1", Result("X", &x), Body(func(context.Context) error { x = 1; return nil }))
+	p.MustDoOverride("X", "provideX2", Result("X", &x), Body(func(context.Context) error { x = 2; return nil }))
+	p.MustDoOverride("X", "provideX3", Result("X", &x), Body(func(context.Context) error { x = 3; return nil }))
+	p.MustDoNewFunction("useX", Argument("X", &y), Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+	assert.Equal(t, 3, y)
+}
+
+func TestProgram_Override_Errors(t *testing.T) {
+	var p Program
+	var x int
+	var s string
+	p.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }))
+	err := p.DoOverride("Y", "provideY", Result("Y", &x), Body(func(context.Context) error { return nil }))
+	assertEqualError(t, err, `di: invalid override: value not found; valueName="Y" functionName="provideY" location="override_test.go:*"`)
+	err = p.DoOverride("X", "provideY", Result("Y", &x), Body(func(context.Context) error { return nil }))
+	assertEqualError(t, err, `di: invalid override: value not produced; valueName="X" overriddenFunctionName="provideX" functionName="provideY" location="override_test.go:*"`)
+	err = p.DoOverride("X", "provideX2", Result("X", &s), Body(func(context.Context) error { return nil }))
+	assert.ErrorIs(t, err, ErrInvalidOverride)
+	assertEqualError(t, err, `di: invalid override: value type mismatch; valueName="X" valueType="string" overriddenValueType="int" overriddenFunctionName="provideX" functionName="provideX2" location="override_test.go:*"`)
+	err = p.DoOverride("X", "provideX2", Result("X", &x))
+	assert.ErrorIs(t, err, ErrBodyRequired)
+	assert.Len(t, p.Graph().Functions, 1)
+	assert.False(t, p.Graph().Functions[0].IsOverridden)
+	assert.NoError(t, p.Validate())
+}
+
+func TestProgram_Override_OtherResults(t *testing.T) {
+	var p Program
+	var a, b, b2 int
+	p.MustDoNewFunction("provideAB", Result("A", &a), Result("B", &b), Body(func(context.Context) error {
+		a, b = 1, 2
+		return nil
+	}))
+	p.MustDoNewFunction("useB", Argument("B", &b2), Body(func(context.Context) error { return nil }))
+	err := p.DoOverride("A", "provideA", Result("A", &a), Body(func(context.Context) error { return nil }))
+	assert.ErrorIs(t, err, ErrInvalidOverride)
+	assertEqualError(t, err, `di: invalid override: value not produced; valueName="B" overriddenFunctionName="provideAB" functionName="provideA" location="override_test.go:*"`)
+	assert.Len(t, p.Graph().Functions, 2)
+	p.MustRun(context.Background())
+	assert.Equal(t, 2, b2)
+
+	p = Program{}
+	p.MustDoNewFunction("provideAB", Result("A", &a), Result("B", &b), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("useB", Argument("B", &b2), Body(func(context.Context) error { return nil }))
+	p.MustDoOverride("A", "provideAB2", Result("A", &a), Result("B", &b), Body(func(context.Context) error {
+		a, b = 10, 20
+		return nil
+	}))
+	p.MustRun(context.Background())
+	assert.Equal(t, 20, b2)
+}
@@ -0,0 +1,38 @@
+package di
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Phase is the phase of calling a DI Function.
+type Phase string
+
+const (
+	// PhaseBody is the phase of calling the body of a DI Function.
+	PhaseBody = Phase("body")
+
+	// PhaseHook is the phase of calling a hook callback of a DI Function.
+	PhaseHook = Phase("hook")
+
+	// PhaseCleanup is the phase of calling the cleanup of a DI Function.
+	PhaseCleanup = Phase("cleanup")
+)
+
+// Keys of pprof labels set when RunOptions.PprofLabels is true.
+const (
+	PprofLabelFunction = "di_function"
+	PprofLabelPhase    = "di_phase"
+	PprofLabelValue    = "di_value"
+)
+
+// doWithPprofLabels calls f with pprof labels if RunOptions.PprofLabels is true, the value is
+// the value ref of a hook, or comma-separated value names of the results of a DI Function.
+func (p *Program) doWithPprofLabels(ctx context.Context, functionName string, phase Phase, value string, f func(context.Context)) {
+	if !p.RunOptions.PprofLabels {
+		f(ctx)
+		return
+	}
+	labels := pprof.Labels(PprofLabelFunction, functionName, PprofLabelPhase, string(phase), PprofLabelValue, value)
+	pprof.Do(ctx, labels, f)
+}
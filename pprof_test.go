@@ -0,0 +1,58 @@
+package di_test
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func pprofLabels(ctx context.Context) map[string]string {
+	labels := make(map[string]string)
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels[key] = value
+		return true
+	})
+	return labels
+}
+
+func TestRunOptions_PprofLabels(t *testing.T) {
+	for _, pprofLabelsEnabled := range []bool{false, true} {
+		var p Program
+		p.RunOptions.PprofLabels = pprofLabelsEnabled
+		var records []map[string]string
+		var x, y int
+		p.MustNewFunction(
+			Result("X", &x),
+			Result("Y", &y),
+			Body(func(ctx context.Context) error {
+				records = append(records, pprofLabels(ctx))
+				return nil
+			}),
+		)
+		p.MustNewFunction(
+			Hook("X", &x, func(ctx context.Context) error {
+				records = append(records, pprofLabels(ctx))
+				return nil
+			}),
+			Body(func(ctx context.Context) error {
+				records = append(records, pprofLabels(ctx))
+				return nil
+			}),
+		)
+		p.MustRun(context.Background())
+		if !pprofLabelsEnabled {
+			assert.Equal(t, []map[string]string{{}, {}, {}}, records)
+			continue
+		}
+		const functionName = "github.com/go-tk/di_test.TestRunOptions_PprofLabels"
+		assert.Equal(t, []map[string]string{
+			{PprofLabelFunction: functionName, PprofLabelPhase: "body", PprofLabelValue: ""},
+			{PprofLabelFunction: functionName, PprofLabelPhase: "body", PprofLabelValue: "X,Y"},
+			{PprofLabelFunction: functionName, PprofLabelPhase: "hook", PprofLabelValue: "X"},
+		}, records)
+		assert.Empty(t, pprofLabels(context.Background()))
+	}
+}
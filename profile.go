@@ -0,0 +1,158 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Profiles restricts a DI Function to the given profiles, the DI Function is active only if
+// any of the profiles is active, see RunOptions.ActiveProfiles. Inactive DI Functions are
+// excluded from the Program, so that DI Functions of different profiles can produce values
+// with the same name.
+//
+// Program.Run() checks only the active profiles, while Program.Validate() additionally checks
+// each profile for missing values, duplicate value names and incompatible value receivers, so
+// that the wiring of every environment can be verified at once.
+func Profiles(profiles ...string) FunctionBuilder {
+	location := callerLocation(1)
+	return func(function *function, program *Program) error {
+		if len(profiles) == 0 {
+			return fmt.Errorf("%w: no profile; functionName=%q location=%q", ErrInvalidProfile, function.Name, location)
+		}
+		for _, profile := range profiles {
+			if profile == "" {
+				return fmt.Errorf("%w: empty profile; functionName=%q location=%q", ErrInvalidProfile, function.Name, location)
+			}
+		}
+		function.Profiles = append(function.Profiles, profiles...)
+		return nil
+	}
+}
+
+// isActive checks whether the given DI Function is active under the profiles resolved, and
+// not overridden.
+func (p *Program) isActive(functionIndex int) bool {
+	function := &p.functions[functionIndex]
+	if function.IsOverridden {
+		return false
+	}
+	if len(function.Profiles) == 0 {
+		return true
+	}
+	for _, profile := range function.Profiles {
+		for _, activeProfile := range p.activeProfiles {
+			if profile == activeProfile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkProfiles resolves values with each profile of DI Functions activated alone, and returns
+// a ValueNotFoundError for each missing value, a DuplicateValueNameError for each duplicate value
+// name and an IncompatibleReceiverError for each incompatible value receiver, collected as
+// WiringErrors. A problem is reported only once, with the first profile, in alphabetical order,
+// having it.
+//
+// Combinations of profiles need not be checked: activating more profiles only adds producers,
+// so a value missing under a combination of profiles is also missing under a profile of the
+// combination activating the consumer. Value names duplicate only under combinations of profiles
+// are allowed, as they are produced by DI Functions of disjoint profiles.
+func (p *Program) checkProfiles() error {
+	profiles := p.profiles()
+	if len(profiles) == 0 {
+		return nil
+	}
+	activeProfiles := p.activeProfiles
+	defer func() {
+		p.activeProfiles = activeProfiles
+		_ = p.doResolve()
+	}()
+	type problemKey struct {
+		Kind      string
+		ValueRef  string
+		Location1 Location
+		Location2 Location
+	}
+	isReported := make(map[problemKey]bool)
+	var errs WiringErrors
+	for _, profile := range profiles {
+		p.activeProfiles = []string{profile}
+		err := p.doResolve()
+		if err == nil {
+			continue
+		}
+		for _, err := range err.(WiringErrors) {
+			var key problemKey
+			switch err := err.(type) {
+			case *ValueNotFoundError:
+				key = problemKey{"valueNotFound", err.ValueRef, err.Location, Location{}}
+			case *DuplicateValueNameError:
+				key = problemKey{"duplicateValueName", err.ValueName, err.Location1, err.Location2}
+			case *IncompatibleReceiverError:
+				key = problemKey{"incompatibleReceiver", err.ValueRef, err.Location, Location{}}
+			default:
+				continue
+			}
+			if isReported[key] {
+				continue
+			}
+			isReported[key] = true
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) >= 1 {
+		errs.sort()
+		return errs
+	}
+	return nil
+}
+
+// profiles returns profiles of DI Functions, sorted and deduplicated.
+func (p *Program) profiles() []string {
+	var profiles []string
+	isSeen := make(map[string]bool)
+	for functionIndex := range p.functions {
+		for _, profile := range p.functions[functionIndex].Profiles {
+			if !isSeen[profile] {
+				isSeen[profile] = true
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// profilesOverlap checks whether DI Functions with the given profiles are not separated by
+// profiles, i.e. either has no profile or they share a profile.
+func profilesOverlap(profiles1 []string, profiles2 []string) bool {
+	if len(profiles1) == 0 || len(profiles2) == 0 {
+		return true
+	}
+	for _, profile1 := range profiles1 {
+		for _, profile2 := range profiles2 {
+			if profile1 == profile2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalProfiles(profiles1 []string, profiles2 []string) bool {
+	if len(profiles1) != len(profiles2) {
+		return false
+	}
+	for i := range profiles1 {
+		if profiles1[i] != profiles2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrInvalidProfile is returned by Program.NewFunction() when invalid profiles are specified.
+var ErrInvalidProfile = errors.New("di: invalid profile")
@@ -0,0 +1,139 @@
+package di_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProfileProgram(mailer *string) *Program {
+	var p Program
+	var mailer1, mailer2, cache string
+	p.MustDoNewFunction("provideFakeMailer", Result("MAILER", &mailer1), Body(func(context.Context) error {
+		mailer1 = "fake"
+		return nil
+	}), Profiles("dev", "test"))
+	p.MustDoNewFunction("provideSMTPMailer", Result("MAILER", &mailer2), Body(func(context.Context) error {
+		mailer2 = "smtp"
+		return nil
+	}), Profiles("prod"))
+	p.MustDoNewFunction("provideCache", Result("CACHE", &cache), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("serve", Argument("MAILER", mailer), Argument("CACHE", &cache), Body(func(context.Context) error { return nil }))
+	return &p
+}
+
+func TestProfiles(t *testing.T) {
+	for _, tt := range []struct {
+		ActiveProfiles []string
+		Mailer         string
+	}{
+		{[]string{"dev"}, "fake"},
+		{[]string{"test"}, "fake"},
+		{[]string{"prod"}, "smtp"},
+	} {
+		var mailer string
+		p := newProfileProgram(&mailer)
+		p.RunOptions.ActiveProfiles = tt.ActiveProfiles
+		p.MustRun(context.Background())
+		assert.Equal(t, tt.Mailer, mailer)
+		state := p.State()
+		assert.Len(t, state.SortedFunctionIndexes, 3)
+		graph := p.Graph()
+		for _, functionIndex := range state.SortedFunctionIndexes {
+			assert.True(t, graph.Functions[functionIndex].IsActive)
+		}
+	}
+
+	var mailer string
+	p := newProfileProgram(&mailer)
+	graph := p.Graph()
+	assert.Equal(t, []string{"dev", "test"}, graph.Functions[0].Profiles)
+	assert.False(t, graph.Functions[0].IsActive)
+	assert.True(t, graph.Functions[2].IsActive)
+	err := p.Validate()
+	assertEqualError(t, err, `di: value not found; valueRef="MAILER" functionName="serve" location="profile_test.go:*"`)
+	p.RunOptions.ActiveProfiles = []string{"prod"}
+	assert.NoError(t, p.Validate())
+	p.RunOptions.ActiveProfiles = []string{"dev", "prod"}
+	err = p.Validate()
+	assert.ErrorIs(t, err, ErrDuplicateValueName)
+}
+
+func TestProfiles_Validate(t *testing.T) {
+	var mailer string
+	p := newProfileProgram(&mailer)
+	var cache string
+	p.MustDoNewFunction("provideRedis", Result("REDIS", &cache), Body(func(context.Context) error { return nil }), Profiles("prod"))
+	p.MustDoNewFunction("warmCache", Argument("REDIS", &cache), Body(func(context.Context) error { return nil }), Profiles("test", "prod"))
+	p.RunOptions.ActiveProfiles = []string{"prod"}
+	err := p.Validate()
+	assert.ErrorIs(t, err, ErrValueNotFound)
+	assertEqualError(t, err, `di: value not found; valueRef="REDIS" functionName="warmCache" location="profile_test.go:*" activeProfiles=["test"]`)
+	// The wiring of the inactive profile does not prevent the Program from running.
+	assert.NoError(t, p.Run(context.Background()))
+	assert.Equal(t, "smtp", mailer)
+
+	p = newProfileProgram(&mailer)
+	err = p.Validate()
+	assertEqualError(t, err, `di: value not found; valueRef="MAILER" functionName="serve" location="profile_test.go:*"`)
+}
+
+func TestProfiles_Include(t *testing.T) {
+	var mailer string
+	p := newProfileProgram(&mailer)
+	var p2 Program
+	var mailer2 string
+	p2.MustDoNewFunction("provideLogMailer", Result("MAILER", &mailer2), Body(func(context.Context) error { return nil }), Profiles("staging"))
+	assert.NoError(t, p.Include(&p2))
+
+	var p3 Program
+	p3.MustDoNewFunction("provideMockMailer", Result("MAILER", &mailer2), Body(func(context.Context) error { return nil }), Profiles("test"))
+	err := p.Include(&p3)
+	assert.ErrorIs(t, err, ErrDuplicateValueName)
+	assertEqualError(t, err, `di: duplicate value name; valueName="MAILER" functionName1="provideFakeMailer" functionName2="provideMockMailer" location1="profile_test.go:*" location2="profile_test.go:*"`)
+}
+
+func TestProfiles_Errors(t *testing.T) {
+	var p Program
+	err := p.DoNewFunction("foo", Body(func(context.Context) error { return nil }), Profiles())
+	assertEqualError(t, err, `di: invalid profile: no profile; functionName="foo" location="profile_test.go:*"`)
+	err = p.DoNewFunction("foo", Body(func(context.Context) error { return nil }), Profiles("dev", ""))
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+}
+
+func TestProfiles_ManyProfiles(t *testing.T) {
+	var p Program
+	for i := 0; i < 30; i++ {
+		var x int
+		p.MustDoNewFunction(fmt.Sprintf("provideX%d", i), Result("X", &x), Body(func(context.Context) error { return nil }),
+			Profiles(fmt.Sprintf("profile%d", i)))
+	}
+	var x int
+	p.MustDoNewFunction("useX", Argument("X", &x), Body(func(context.Context) error { return nil }))
+	p.RunOptions.ActiveProfiles = []string{"profile0"}
+	assert.NoError(t, p.Validate())
+}
+
+func TestProfiles_ValidateConflicts(t *testing.T) {
+	var p Program
+	var x int
+	var s string
+	body := Body(func(context.Context) error { return nil })
+	p.MustDoNewFunction("provideX1", Result("X", &x), body, Profiles("test"))
+	p.MustDoNewFunction("provideX2", Result("X", &x), body, Profiles("test", "dev"))
+	p.MustDoNewFunction("provideY", Result("Y", &x), body, Profiles("prod"))
+	p.MustDoNewFunction("useY", Argument("Y", &s), body, Profiles("dev", "prod"))
+	p.RunOptions.ActiveProfiles = []string{"staging"}
+	err := p.Validate()
+	assert.ErrorIs(t, err, ErrDuplicateValueName)
+	assert.ErrorIs(t, err, ErrIncompatibleValueReceiver)
+	assert.ErrorIs(t, err, ErrValueNotFound)
+	assertEqualError(t, err, ``+
+		`di: duplicate value name; valueName="X" functionName1="provideX2" functionName2="provideX1" location1="profile_test.go:*" location2="profile_test.go:*" activeProfiles=["test"]`+"\n"+
+		`di: incompatible value receiver; valueReceiverType="string" valueType="int" valueRef="Y" functionName="useY" location="profile_test.go:*" activeProfiles=["prod"]`+"\n"+
+		`di: value not found; valueRef="Y" functionName="useY" location="profile_test.go:*" activeProfiles=["dev"]`)
+	assert.NoError(t, p.Run(context.Background()))
+}
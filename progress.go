@@ -0,0 +1,33 @@
+package di
+
+// Progress is the progress of Program.Run(), see RunOptions.Progress.
+type Progress struct {
+	// Completed is the number of DI Functions whose bodies have been called.
+	Completed int
+
+	// Total is the number of DI Functions to call.
+	Total int
+
+	// FunctionName is the name of the DI Function being called, it is empty when all DI
+	// Functions have been called.
+	FunctionName string
+
+	// Phase is the phase of the DI Function being called, it is PhaseBody or PhaseHook, or empty
+	// when all DI Functions have been called.
+	Phase Phase
+}
+
+func (p *Program) reportProgress(functionName string, phase Phase) {
+	if p.RunOptions.Progress == nil {
+		return
+	}
+	p.mutex.Lock()
+	progress := Progress{
+		Completed:    p.calledFunctionCount,
+		Total:        len(p.sortedFunctionIndexes),
+		FunctionName: functionName,
+		Phase:        phase,
+	}
+	p.mutex.Unlock()
+	p.RunOptions.Progress(progress)
+}
@@ -0,0 +1,65 @@
+// Package progress provides a renderer drawing the progress of Program.Run() as a single line
+// on a terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/go-tk/di"
+)
+
+// BarWidth is the width of progress bars, in characters.
+const BarWidth = 20
+
+// Renderer draws the progress of Program.Run() as a single line, which is redrawn in place
+// with carriage returns, and ended with a line feed once all DI Functions have been called.
+type Renderer struct {
+	w io.Writer
+
+	mutex         sync.Mutex
+	lastLineWidth int
+}
+
+// NewRenderer returns a Renderer drawing on the given writer, typically os.Stderr.
+// Renderer.Report can be used as RunOptions.Progress.
+func NewRenderer(w io.Writer) *Renderer {
+	return &Renderer{w: w}
+}
+
+// Report draws the given progress.
+func (r *Renderer) Report(progress di.Progress) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	line := formatLine(progress)
+	lineWidth := len([]rune(line))
+	padding := ""
+	if lineWidth < r.lastLineWidth {
+		padding = strings.Repeat(" ", r.lastLineWidth-lineWidth)
+	}
+	r.lastLineWidth = lineWidth
+	if progress.Completed >= progress.Total && progress.FunctionName == "" {
+		fmt.Fprintf(r.w, "\r%s%s\n", line, padding)
+		r.lastLineWidth = 0
+		return
+	}
+	fmt.Fprintf(r.w, "\r%s%s", line, padding)
+}
+
+func formatLine(progress di.Progress) string {
+	doneWidth := 0
+	if progress.Total >= 1 {
+		doneWidth = BarWidth * progress.Completed / progress.Total
+	}
+	bar := strings.Repeat("=", doneWidth)
+	if doneWidth < BarWidth {
+		bar += ">" + strings.Repeat(" ", BarWidth-doneWidth-1)
+	}
+	line := fmt.Sprintf("[%s] %d/%d", bar, progress.Completed, progress.Total)
+	if progress.FunctionName == "" {
+		return line + " done"
+	}
+	return fmt.Sprintf("%s %s %s", line, progress.Phase, progress.FunctionName)
+}
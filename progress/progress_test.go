@@ -0,0 +1,33 @@
+package progress_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/go-tk/di"
+	. "github.com/go-tk/di/progress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderer_Report(t *testing.T) {
+	var buffer bytes.Buffer
+	renderer := NewRenderer(&buffer)
+	renderer.Report(di.Progress{Completed: 0, Total: 4, FunctionName: "pkg.provideConfiguration", Phase: di.PhaseBody})
+	renderer.Report(di.Progress{Completed: 1, Total: 4, FunctionName: "pkg.provideDB", Phase: di.PhaseHook})
+	renderer.Report(di.Progress{Completed: 4, Total: 4})
+	assert.Equal(t, ""+
+		"\r[>                   ] 0/4 body pkg.provideConfiguration"+
+		"\r[=====>              ] 1/4 hook pkg.provideDB           "+
+		"\r[====================] 4/4 done              \n", buffer.String())
+}
+
+func TestRenderer(t *testing.T) {
+	var program di.Program
+	var buffer bytes.Buffer
+	program.RunOptions.Progress = NewRenderer(&buffer).Report
+	program.MustNewFunction(di.Body(func(context.Context) error { return nil }))
+	program.MustRun(context.Background())
+	assert.Equal(t, "\r[>                   ] 0/1 body github.com/go-tk/di/progress_test.TestRenderer"+
+		"\r[====================] 1/1 done                                               \n", buffer.String())
+}
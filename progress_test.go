@@ -0,0 +1,27 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunOptions_Progress(t *testing.T) {
+	var p Program
+	var progresses []Progress
+	p.RunOptions.Progress = func(progress Progress) { progresses = append(progresses, progress) }
+	var x int
+	p.MustDoNewFunction("foo", Result("X", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("bar", Argument("X", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("baz", Hook("X", &x, func(context.Context) error { return nil }), Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+	assert.Equal(t, []Progress{
+		{Completed: 0, Total: 3, FunctionName: "baz", Phase: PhaseBody},
+		{Completed: 1, Total: 3, FunctionName: "foo", Phase: PhaseBody},
+		{Completed: 2, Total: 3, FunctionName: "baz", Phase: PhaseHook},
+		{Completed: 2, Total: 3, FunctionName: "bar", Phase: PhaseBody},
+		{Completed: 3, Total: 3},
+	}, progresses)
+}
@@ -0,0 +1,191 @@
+package di
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type functionTiming struct {
+	FunctionIndex int
+	BodyStartTime time.Time
+	BodyEndTime   time.Time
+	HookTimings   []hookTiming
+	Err           error
+}
+
+type hookTiming struct {
+	HookIndex int
+	StartTime time.Time
+	EndTime   time.Time
+	Err       error
+}
+
+// RunReport is the result of Program.RunReport().
+type RunReport struct {
+	// TotalDuration is the duration of Program.Run().
+	TotalDuration time.Duration
+
+	// Functions are DI Functions called, in the order in which they were called.
+	Functions []FunctionReport
+
+	// CriticalPath is the chain of DI Functions, from the first to the last, with the longest
+	// total duration, in which each DI Function depends on the previous one.
+	CriticalPath []FunctionRef
+
+	// CriticalPathDuration is the total duration of DI Functions on the critical path.
+	CriticalPathDuration time.Duration
+}
+
+// FunctionReport describes a call to a DI Function.
+type FunctionReport struct {
+	FunctionRef
+
+	StartTime    time.Time
+	BodyDuration time.Duration
+
+	// Hooks are hook callbacks called right after the body, for the results of the DI Function.
+	Hooks []HookReport
+
+	// Duration is the duration of the body plus durations of hook callbacks.
+	Duration time.Duration
+
+	// Err is the error returned by the body or a hook callback.
+	Err error
+}
+
+// HookReport describes a call to a hook callback.
+type HookReport struct {
+	FunctionName string
+	ValueRef     string
+	Location     Location
+	StartTime    time.Time
+	Duration     time.Duration
+	Err          error
+}
+
+// RunReport returns the timing report of the last Program.Run().
+func (p *Program) RunReport() *RunReport {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var report RunReport
+	report.TotalDuration = p.runEndTime.Sub(p.runStartTime)
+	if report.TotalDuration < 0 {
+		report.TotalDuration = 0
+	}
+	functionIndex2Duration := make(map[int]time.Duration, len(p.functionTimings))
+	for i := range p.functionTimings {
+		functionTiming := &p.functionTimings[i]
+		function := &p.functions[functionTiming.FunctionIndex]
+		functionReport := FunctionReport{
+			FunctionRef:  FunctionRef{function.Name, function.Location},
+			StartTime:    functionTiming.BodyStartTime,
+			BodyDuration: functionTiming.BodyEndTime.Sub(functionTiming.BodyStartTime),
+			Err:          functionTiming.Err,
+		}
+		functionReport.Duration = functionReport.BodyDuration
+		for j := range functionTiming.HookTimings {
+			hookTiming := &functionTiming.HookTimings[j]
+			hook := &p.hooks[hookTiming.HookIndex]
+			hookReport := HookReport{
+				FunctionName: p.functions[hook.FunctionIndex].Name,
+				ValueRef:     hook.ValueRef,
+				Location:     hook.Location,
+				StartTime:    hookTiming.StartTime,
+				Duration:     hookTiming.EndTime.Sub(hookTiming.StartTime),
+				Err:          hookTiming.Err,
+			}
+			functionReport.Hooks = append(functionReport.Hooks, hookReport)
+			functionReport.Duration += hookReport.Duration
+			if hookReport.Err != nil {
+				functionReport.Err = hookReport.Err
+			}
+		}
+		report.Functions = append(report.Functions, functionReport)
+		functionIndex2Duration[functionTiming.FunctionIndex] = functionReport.Duration
+	}
+	report.CriticalPath, report.CriticalPathDuration = p.findCriticalPath(functionIndex2Duration)
+	return &report
+}
+
+func (p *Program) findCriticalPath(functionIndex2Duration map[int]time.Duration) ([]FunctionRef, time.Duration) {
+	pathDurations := make(map[int]time.Duration, len(functionIndex2Duration))
+	prevFunctionIndexes := make(map[int]int, len(functionIndex2Duration))
+	lastFunctionIndex := -1
+	for i := range p.functionTimings {
+		functionIndex := p.functionTimings[i].FunctionIndex
+		prevFunctionIndex := -1
+		var prevPathDuration time.Duration
+		for _, dependency := range p.dependencies(&p.functions[functionIndex]) {
+			if pathDuration, ok := pathDurations[dependency.FunctionIndex]; ok && (prevFunctionIndex < 0 || pathDuration > prevPathDuration) {
+				prevFunctionIndex = dependency.FunctionIndex
+				prevPathDuration = pathDuration
+			}
+		}
+		pathDurations[functionIndex] = prevPathDuration + functionIndex2Duration[functionIndex]
+		prevFunctionIndexes[functionIndex] = prevFunctionIndex
+		if lastFunctionIndex < 0 || pathDurations[functionIndex] > pathDurations[lastFunctionIndex] {
+			lastFunctionIndex = functionIndex
+		}
+	}
+	if lastFunctionIndex < 0 {
+		return nil, 0
+	}
+	var criticalPath []FunctionRef
+	for functionIndex := lastFunctionIndex; functionIndex >= 0; functionIndex = prevFunctionIndexes[functionIndex] {
+		function := &p.functions[functionIndex]
+		criticalPath = append(criticalPath, FunctionRef{function.Name, function.Location})
+	}
+	for i, j := 0, len(criticalPath)-1; i < j; i, j = i+1, j-1 {
+		criticalPath[i], criticalPath[j] = criticalPath[j], criticalPath[i]
+	}
+	return criticalPath, pathDurations[lastFunctionIndex]
+}
+
+// Slowest returns at most n DI Functions with the longest durations.
+func (r *RunReport) Slowest(n int) []FunctionReport {
+	functionReports := make([]FunctionReport, len(r.Functions))
+	copy(functionReports, r.Functions)
+	sort.SliceStable(functionReports, func(i, j int) bool {
+		return functionReports[i].Duration > functionReports[j].Duration
+	})
+	if len(functionReports) > n {
+		functionReports = functionReports[:n]
+	}
+	return functionReports
+}
+
+// WriteTable writes the report as a table.
+func (r *RunReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FUNCTION\tBODY\tHOOKS\tTOTAL\tERROR")
+	for i := range r.Functions {
+		functionReport := &r.Functions[i]
+		errStr := ""
+		if functionReport.Err != nil {
+			errStr = functionReport.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%s\n", functionReport.Name, functionReport.BodyDuration,
+			functionReport.Duration-functionReport.BodyDuration, functionReport.Duration, errStr)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	functionNames := make([]string, len(r.CriticalPath))
+	for i, functionRef := range r.CriticalPath {
+		functionNames[i] = functionRef.Name
+	}
+	_, err := fmt.Fprintf(w, "total: %v\ncritical path (%v): %s\n", r.TotalDuration, r.CriticalPathDuration,
+		strings.Join(functionNames, " => "))
+	return err
+}
+
+// String returns the report as a table.
+func (r *RunReport) String() string {
+	var builder strings.Builder
+	r.WriteTable(&builder)
+	return builder.String()
+}
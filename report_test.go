@@ -0,0 +1,61 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_RunReport(t *testing.T) {
+	var p Program
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.SetNow(func() time.Time { return now })
+	sleep := func(d time.Duration) func(context.Context) error {
+		return func(context.Context) error {
+			now = now.Add(d)
+			return nil
+		}
+	}
+	var config, db, cache int
+	p.MustDoNewFunction("provideConfig", Result("CONFIG", &config), Body(sleep(10*time.Millisecond)))
+	p.MustDoNewFunction("provideDB", Argument("CONFIG", &config), Result("DB", &db), Body(sleep(100*time.Millisecond)))
+	p.MustDoNewFunction("provideCache", Argument("CONFIG", &config), Result("CACHE", &cache), Body(sleep(50*time.Millisecond)))
+	p.MustDoNewFunction("serve", Argument("DB", &db), Argument("CACHE", &cache), Body(sleep(time.Millisecond)))
+	p.MustDoNewFunction("migrateDB", Body(sleep(0)), Hook("DB", &db, sleep(30*time.Millisecond)))
+	if !assert.NoError(t, p.Run(context.Background())) {
+		t.FailNow()
+	}
+
+	report := p.RunReport()
+	assert.Equal(t, 191*time.Millisecond, report.TotalDuration)
+	if assert.Len(t, report.Functions, 5) {
+		functionReport := report.Functions[2]
+		assert.Equal(t, "provideDB", functionReport.Name)
+		assert.Equal(t, 100*time.Millisecond, functionReport.BodyDuration)
+		assert.Equal(t, 130*time.Millisecond, functionReport.Duration)
+		if assert.Len(t, functionReport.Hooks, 1) {
+			assert.Equal(t, "migrateDB", functionReport.Hooks[0].FunctionName)
+			assert.Equal(t, "DB", functionReport.Hooks[0].ValueRef)
+			assert.Equal(t, 30*time.Millisecond, functionReport.Hooks[0].Duration)
+		}
+	}
+	slowest := report.Slowest(2)
+	if assert.Len(t, slowest, 2) {
+		assert.Equal(t, "provideDB", slowest[0].Name)
+		assert.Equal(t, "provideCache", slowest[1].Name)
+	}
+	assert.Equal(t, 141*time.Millisecond, report.CriticalPathDuration)
+	assert.Equal(t, `
+FUNCTION       BODY   HOOKS  TOTAL  ERROR
+provideConfig  10ms   0s     10ms   
+migrateDB      0s     0s     0s     
+provideDB      100ms  30ms   130ms  
+provideCache   50ms   0s     50ms   
+serve          1ms    0s     1ms    
+total: 191ms
+critical path (141ms): provideConfig => provideDB => serve
+`[1:], report.String())
+}
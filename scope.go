@@ -0,0 +1,166 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+)
+
+// NewScope returns a child Program of the Program, which inherits RunOptions. Arguments and hooks
+// of DI Functions added into the child Program can reference values produced by the Program,
+// or its ancestors, when they cannot be resolved within the child Program.
+//
+// Values inherited must have been produced, i.e. the DI Functions producing them must have been
+// called, by the time the child Program is validated, and must not be produced by transient DI
+// Functions, see Transient(). Values whose producers have been disabled by conditions are absent,
+// as within a Program, see When().
+//
+// Run() of the child Program calls only its own DI Functions, hooks on values inherited are called
+// right after the bodies of their DI Functions, and Clean() of the child Program calls only its own
+// cleanups, leaving the Program intact.
+func (p *Program) NewScope() *Program {
+	return &Program{
+		RunOptions: p.RunOptions,
+		parent:     p,
+		now:        p.now,
+	}
+}
+
+type inheritedValue struct {
+	Value              reflect.Value
+	ReceiveValueAddr   bool
+	ProducerName       string
+	ProducerModuleName string
+	IsVisible          bool
+	IsTransient        bool
+	IsProduced         bool
+
+	// DisabledCondition is the condition disabling the producer, or nil if none, see When().
+	DisabledCondition *condition
+	// ConditionValueRef is the value ref of DisabledCondition set by WhenValue().
+	ConditionValueRef string
+}
+
+// resolveInheritedValue resolves the given value ref to a value produced by an ancestor of the
+// Program, it reports false if the value does not exist. If the producer is disabled by a
+// condition, the value resolved is invalid when the value is optional.
+func (p *Program) resolveInheritedValue(valueRef string, valueReceiver reflect.Value, isOptional bool, functionIndex int,
+	location Location, kind DependencyKind) (inheritedValue, bool, error) {
+	function := &p.functions[functionIndex]
+	for ancestor := p.parent; ancestor != nil; ancestor = ancestor.parent {
+		value, ok := ancestor.lookUpOwnValue(valueRef, function.ModuleName)
+		if !ok {
+			continue
+		}
+		if !value.IsVisible {
+			return inheritedValue{}, true, &PrivateValueError{
+				ValueRef:        valueRef,
+				ValueModuleName: value.ProducerModuleName,
+				FunctionName:    function.Name,
+				ModuleName:      function.ModuleName,
+				Location:        location,
+				Kind:            kind,
+			}
+		}
+		if value.IsTransient {
+			return inheritedValue{}, true, &TransientValueInheritedError{
+				ValueRef:     valueRef,
+				ProducerName: value.ProducerName,
+				FunctionName: function.Name,
+				ModuleName:   function.ModuleName,
+				Location:     location,
+				Kind:         kind,
+			}
+		}
+		if condition := value.DisabledCondition; condition != nil {
+			if isOptional {
+				return inheritedValue{}, true, nil
+			}
+			return inheritedValue{}, true, &ProducerDisabledError{
+				ValueRef:          valueRef,
+				ProducerName:      value.ProducerName,
+				ConditionLocation: condition.Location,
+				ConditionValueRef: value.ConditionValueRef,
+				FunctionName:      function.Name,
+				ModuleName:        function.ModuleName,
+				Location:          location,
+			}
+		}
+		if !value.IsProduced {
+			return inheritedValue{}, true, &ValueNotProducedError{
+				ValueRef:     valueRef,
+				ProducerName: value.ProducerName,
+				FunctionName: function.Name,
+				ModuleName:   function.ModuleName,
+				Location:     location,
+				Kind:         kind,
+			}
+		}
+		valueType := value.Value.Type()
+		valueReceiverType := valueReceiver.Type()
+		if valueReceiverType == reflect.PtrTo(valueType) {
+			value.ReceiveValueAddr = true
+		} else {
+			if !valueType.AssignableTo(valueReceiverType) {
+				return inheritedValue{}, true, &IncompatibleReceiverError{
+					ValueRef:          valueRef,
+					ValueReceiverType: valueReceiverType,
+					ValueType:         valueType,
+					FunctionName:      function.Name,
+					ModuleName:        function.ModuleName,
+					Location:          location,
+					Kind:              kind,
+				}
+			}
+		}
+		return value, true, nil
+	}
+	return inheritedValue{}, false, nil
+}
+
+// lookUpOwnValue looks up a value among results of the Program's own DI Functions, for arguments
+// and hooks of DI Functions, in the given Module, of a child Program.
+func (p *Program) lookUpOwnValue(valueRef string, moduleName string) (inheritedValue, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	valueName2ResultIndex := make(map[string]int, len(p.results))
+	for resultIndex := range p.results {
+		if !p.isActive(p.results[resultIndex].FunctionIndex) {
+			continue
+		}
+		valueName := p.results[resultIndex].ValueName
+		if _, ok := valueName2ResultIndex[valueName]; !ok {
+			valueName2ResultIndex[valueName] = resultIndex
+		}
+	}
+	resultIndex, ok := p.lookUpValue(valueRef, moduleName, valueName2ResultIndex)
+	if !ok {
+		return inheritedValue{}, false
+	}
+	result := &p.results[resultIndex]
+	producer := &p.functions[result.FunctionIndex]
+	value := inheritedValue{
+		Value:              result.Value,
+		ProducerName:       producer.Name,
+		ProducerModuleName: producer.ModuleName,
+		IsVisible:          p.isVisible(resultIndex, moduleName),
+		IsTransient:        producer.IsTransient,
+	}
+	for _, functionIndex := range p.calledFunctionIndexes() {
+		if functionIndex == producer.Index {
+			if condition, ok := p.disabledFunctions[functionIndex]; ok {
+				value.DisabledCondition = condition
+				if condition.ArgumentIndex >= 0 {
+					value.ConditionValueRef = p.arguments[condition.ArgumentIndex].ValueRef
+				}
+			} else {
+				value.IsProduced = true
+			}
+			break
+		}
+	}
+	return value, true
+}
+
+// ErrValueNotProduced is returned by Program.Validate()/Program.Run() of a child Program when a
+// value used by Argument()/Hook() is inherited but has not been produced, see Program.NewScope().
+var ErrValueNotProduced = errors.New("di: value not produced")
@@ -0,0 +1,74 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_NewScope(t *testing.T) {
+	var p Program
+	var log []string
+	var pool, logger string
+	p.MustDoNewFunction("providePool", Result("POOL", &pool), Body(func(context.Context) error {
+		pool = "pool"
+		return nil
+	}), Cleanup(func() { log = append(log, "close pool") }))
+	p.MustDoNewFunction("provideLogger", Result("LOGGER", &logger), Body(func(context.Context) error {
+		logger = "logger"
+		return nil
+	}))
+
+	scope := p.NewScope()
+	var pool2, pool3, job string
+	scope.MustDoNewFunction("provideJob", Argument("POOL", &pool2), Result("JOB", &job), Body(func(context.Context) error {
+		job = "job using " + pool2
+		return nil
+	}), Cleanup(func() { log = append(log, "close job") }))
+	scope.MustDoNewFunction("watchPool", Hook("POOL", &pool3, func(context.Context) error {
+		log = append(log, "hook "+pool3)
+		return nil
+	}), Body(func(context.Context) error { return nil }))
+	assertEqualError(t, scope.Validate(), ``+
+		`di: value not produced; valueRef="POOL" producerName="providePool" functionName="provideJob" location="scope_test.go:*"`+"\n"+
+		`di: value not produced; valueRef="POOL" producerName="providePool" functionName="watchPool" location="scope_test.go:*"`)
+
+	p.MustRun(context.Background())
+	scope.MustRun(context.Background())
+	assert.Equal(t, "job using pool", job)
+	assert.Equal(t, []string{"hook pool"}, log)
+	assert.Equal(t, 2, scope.State().CalledFunctionCount)
+	graph := scope.Graph()
+	assert.True(t, graph.Functions[0].Arguments[0].IsInherited)
+	assert.Equal(t, -1, graph.Functions[0].Arguments[0].ProducerIndex)
+	assert.True(t, graph.Functions[1].Hooks[0].IsInherited)
+
+	scope.Clean()
+	assert.Equal(t, []string{"hook pool", "close job"}, log)
+	assert.Equal(t, 2, p.State().CalledFunctionCount)
+	p.Clean()
+	assert.Equal(t, []string{"hook pool", "close job", "close pool"}, log)
+
+	var loggerPtr *string
+	var x int
+	nestedScope := scope.NewScope()
+	nestedScope.MustDoNewFunction("useLogger", Argument("LOGGER", &loggerPtr), Argument("JOB", &job), Body(func(context.Context) error { return nil }))
+	nestedScope.MustDoNewFunction("useBadLogger", Argument("LOGGER", &x), Body(func(context.Context) error { return nil }))
+	assertEqualError(t, nestedScope.Validate(),
+		`di: incompatible value receiver; valueReceiverType="int" valueType="string" valueRef="LOGGER" functionName="useBadLogger" location="scope_test.go:*"`)
+}
+
+func TestProgram_NewScope_PrivateResult(t *testing.T) {
+	var p Program
+	var config string
+	p.MustInstall(NewModule("db", func(p *Program) {
+		p.MustDoNewFunction("provideConfig", PrivateResult("CONFIG", &config), Body(func(context.Context) error { return nil }))
+	}))
+	p.MustRun(context.Background())
+	scope := p.NewScope()
+	scope.MustDoNewFunction("useConfig", Argument("db.CONFIG", &config), Body(func(context.Context) error { return nil }))
+	assertEqualError(t, scope.Validate(),
+		`di: private value; valueRef="db.CONFIG" valueModuleName="db" functionName="useConfig" location="scope_test.go:*"`)
+}
@@ -0,0 +1,53 @@
+package di
+
+// State is a snapshot of the execution state of a Program. DI Functions are referred to by
+// indexes into Graph.Functions.
+type State struct {
+	// SortedFunctionIndexes are indexes of DI Functions in the order in which they are to be
+	// called, it is empty if the Program has not been validated successfully.
+	SortedFunctionIndexes []int
+
+	// CalledFunctionCount is the number of DI Functions called, counted from the start of
+	// SortedFunctionIndexes.
+	CalledFunctionCount int
+
+	// PendingCleanupIndexes are indexes of DI Functions called whose cleanups have not been
+	// called yet, in the order in which cleanups are to be called. A transient DI Function
+	// appears once for each call to its body.
+	PendingCleanupIndexes []int
+
+	// DisabledFunctionIndexes are indexes of DI Functions disabled by conditions, see When(),
+	// in the order in which they were to be called.
+	DisabledFunctionIndexes []int
+
+	// IsRunning indicates whether Program.Run() is in progress.
+	IsRunning bool
+
+	// RunErr is the error returned by the last call to Program.Run().
+	RunErr error
+}
+
+// State returns a snapshot of the execution state of the Program. It is safe to call State()
+// concurrently with Program.Run()/Program.Clean(), so are Program.Graph() and
+// Program.RunReport().
+func (p *Program) State() State {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var state State
+	state.SortedFunctionIndexes = append(state.SortedFunctionIndexes, p.sortedFunctionIndexes...)
+	state.CalledFunctionCount = p.calledFunctionCount
+	for i := len(p.functionCalls) - p.cleanedCallCount - 1; i >= 0; i-- {
+		functionIndex := p.functionCalls[i].FunctionIndex
+		if p.functions[functionIndex].Cleanup != nil {
+			state.PendingCleanupIndexes = append(state.PendingCleanupIndexes, functionIndex)
+		}
+	}
+	for _, functionIndex := range p.calledFunctionIndexes() {
+		if p.isDisabled(functionIndex) {
+			state.DisabledFunctionIndexes = append(state.DisabledFunctionIndexes, functionIndex)
+		}
+	}
+	state.IsRunning = p.isRunning
+	state.RunErr = p.runErr
+	return state
+}
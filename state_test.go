@@ -0,0 +1,114 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_State(t *testing.T) {
+	var p Program
+	names := func(functionIndexes []int) []string {
+		var names []string
+		for _, functionIndex := range functionIndexes {
+			names = append(names, p.Graph().Functions[functionIndex].Name)
+		}
+		return names
+	}
+	var states []State
+	var x, y int
+	p.MustDoNewFunction("foo", Result("X", &x), Body(func(context.Context) error { return nil }), Cleanup(func() {
+		states = append(states, p.State())
+	}))
+	p.MustDoNewFunction("bar", Argument("X", &x), Result("Y", &y), Body(func(context.Context) error {
+		states = append(states, p.State())
+		return nil
+	}), Cleanup(func() {}))
+	p.MustDoNewFunction("baz", Argument("Y", &y), Body(func(context.Context) error { return errors.New("oops") }))
+
+	state := p.State()
+	assert.Empty(t, state.SortedFunctionIndexes)
+	assert.False(t, state.IsRunning)
+
+	err := p.Run(context.Background())
+	state = p.State()
+	assert.Equal(t, []string{"foo", "bar", "baz"}, names(state.SortedFunctionIndexes))
+	assert.Equal(t, 2, state.CalledFunctionCount)
+	assert.Equal(t, []string{"bar", "foo"}, names(state.PendingCleanupIndexes))
+	assert.False(t, state.IsRunning)
+	assert.Equal(t, err, state.RunErr)
+
+	p.Clean()
+	state = p.State()
+	assert.Empty(t, state.PendingCleanupIndexes)
+
+	if assert.Len(t, states, 2) {
+		assert.True(t, states[0].IsRunning)
+		assert.Equal(t, 1, states[0].CalledFunctionCount)
+		assert.Equal(t, []string{"foo"}, names(states[0].PendingCleanupIndexes))
+		assert.NoError(t, states[0].RunErr)
+		assert.Equal(t, []string{"foo"}, names(states[1].PendingCleanupIndexes))
+	}
+}
+
+func TestProgram_State_Concurrency(t *testing.T) {
+	var p Program
+	var x int
+	p.MustNewFunction(Result("X", &x), Body(func(context.Context) error { return nil }), Cleanup(func() {}))
+	p.MustNewFunction(Argument("X", &x), Body(func(context.Context) error { return nil }))
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = p.State()
+			_ = p.Graph()
+			_ = p.RunReport()
+		}
+	}()
+	p.MustRun(context.Background())
+	p.Clean()
+	close(done)
+	wg.Wait()
+	assert.Equal(t, 2, p.State().CalledFunctionCount)
+}
+
+func TestProgram_State_RunTwice(t *testing.T) {
+	var p Program
+	var x int
+	var cleanupCount int
+	enabled := false
+	p.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }),
+		Cleanup(func() { cleanupCount++ }))
+	p.MustDoNewFunction("useX", Argument("X", &x), Body(func(context.Context) error { return nil }),
+		When(func() bool { return enabled }))
+	p.MustRun(context.Background())
+	assert.Len(t, p.State().DisabledFunctionIndexes, 1)
+	p.Clean()
+	assert.Equal(t, 1, cleanupCount)
+
+	enabled = true
+	p.MustRun(context.Background())
+	state := p.State()
+	assert.Equal(t, 2, state.CalledFunctionCount)
+	assert.Empty(t, state.DisabledFunctionIndexes)
+	assert.Len(t, state.PendingCleanupIndexes, 1)
+	assert.Len(t, p.RunReport().Functions, 2)
+
+	// Cleanups not called yet are kept across runs.
+	p.MustRun(context.Background())
+	assert.Len(t, p.State().PendingCleanupIndexes, 2)
+	p.Clean()
+	assert.Equal(t, 3, cleanupCount)
+	assert.Empty(t, p.State().PendingCleanupIndexes)
+}
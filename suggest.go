@@ -0,0 +1,117 @@
+package di
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxSuggestionCount = 3
+
+// suggestValueNames finds value names which look like the given value ref, ignoring case and
+// separators, and tolerating a few typos. Private values invisible to the given Module are ignored.
+func (p *Program) suggestValueNames(valueRef string, moduleName string, valueName2ResultIndex map[string]int) []Suggestion {
+	type candidate struct {
+		ValueName   string
+		ResultIndex int
+		Distance    int
+	}
+	normalizedValueRef := normalizeValueName(valueRef)
+	var candidates []candidate
+	for valueName, resultIndex := range valueName2ResultIndex {
+		if !p.isVisible(resultIndex, moduleName) {
+			continue
+		}
+		distance, ok := valueNameDistance(normalizedValueRef, valueName)
+		// Value names qualified with module names also match by the unqualified part.
+		if moduleName := p.functions[p.results[resultIndex].FunctionIndex].ModuleName; moduleName != "" {
+			localValueName := strings.TrimPrefix(valueName, moduleName+".")
+			if distance2, ok2 := valueNameDistance(normalizedValueRef, localValueName); ok2 && (!ok || distance2 < distance) {
+				distance, ok = distance2, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, candidate{valueName, resultIndex, distance})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].ValueName < candidates[j].ValueName
+	})
+	if len(candidates) > maxSuggestionCount {
+		candidates = candidates[:maxSuggestionCount]
+	}
+	suggestions := make([]Suggestion, len(candidates))
+	for i, candidate := range candidates {
+		suggestions[i] = Suggestion{
+			ValueName:    candidate.ValueName,
+			FunctionName: p.functions[p.results[candidate.ResultIndex].FunctionIndex].Name,
+		}
+	}
+	return suggestions
+}
+
+func valueNameDistance(normalizedValueRef string, valueName string) (int, bool) {
+	normalizedValueName := normalizeValueName(valueName)
+	distance := editDistance(normalizedValueRef, normalizedValueName)
+	if distance > maxEditDistance(normalizedValueRef, normalizedValueName) {
+		return 0, false
+	}
+	return distance, true
+}
+
+func normalizeValueName(valueName string) string {
+	var builder strings.Builder
+	for _, r := range valueName {
+		switch r {
+		case '_', '-', '.', ' ', '/', ':':
+			continue
+		}
+		builder.WriteRune(unicode.ToLower(r))
+	}
+	return builder.String()
+}
+
+// maxEditDistance allows one typo for every four characters of the shorter string, so short
+// value names are matched only if they are identical after normalization.
+func maxEditDistance(s1 string, s2 string) int {
+	n := utf8.RuneCountInString(s1)
+	if n2 := utf8.RuneCountInString(s2); n2 < n {
+		n = n2
+	}
+	return n / 4
+}
+
+// editDistance returns the Levenshtein distance between the given strings.
+func editDistance(s1 string, s2 string) int {
+	r1, r2 := []rune(s1), []rune(s2)
+	distances := make([]int, len(r2)+1)
+	for j := range distances {
+		distances[j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		prevDistance := distances[0]
+		distances[0] = i
+		for j := 1; j <= len(r2); j++ {
+			distance := prevDistance
+			if r1[i-1] != r2[j-1] {
+				distance++
+				if d := distances[j] + 1; d < distance {
+					distance = d
+				}
+				if d := distances[j-1] + 1; d < distance {
+					distance = d
+				}
+			}
+			prevDistance, distances[j] = distances[j], distance
+		}
+	}
+	return distances[len(r2)]
+}
@@ -0,0 +1,60 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/go-tk/testcase"
+)
+
+func TestProgram_Validate_Suggestions(t *testing.T) {
+	type C struct {
+		valueNames []string
+		valueRef   string
+
+		errStr string
+	}
+	tc := testcase.New(func(t *testing.T, c *C) {
+		testcase.Callback(t, "0")
+
+		var p Program
+		for _, valueName := range c.valueNames {
+			var x int
+			if err := p.DoNewFunction("provide"+valueName, Result(valueName, &x),
+				Body(func(context.Context) error { return nil })); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var x int
+		if err := p.DoNewFunction("consume", Argument(c.valueRef, &x),
+			Body(func(context.Context) error { return nil })); err != nil {
+			t.Fatal(err)
+		}
+		assertEqualError(t, p.Validate(), c.errStr)
+	})
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		c.valueNames = []string{"USER_REPOSITORY", "USER_SERVICE", "ORDER_REPOSITORY"}
+		c.valueRef = "USER_REPOSITRY"
+		c.errStr = ErrValueNotFound.Error() + `; valueRef="USER_REPOSITRY" functionName="consume" location="suggest_test.go:*" didYouMean="USER_REPOSITORY (provideUSER_REPOSITORY)"`
+	}).RunParallel(t)
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		c.valueNames = []string{"user-repository", "UserRepository", "USER_SERVICE"}
+		c.valueRef = "USER_REPOSITORY"
+		c.errStr = ErrValueNotFound.Error() + `; valueRef="USER_REPOSITORY" functionName="consume" location="suggest_test.go:*" didYouMean="UserRepository (provideUserRepository), user-repository (provideuser-repository)"`
+	}).RunParallel(t)
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		c.valueNames = []string{"DB", "DC", "DATABASE"}
+		c.valueRef = "db"
+		c.errStr = ErrValueNotFound.Error() + `; valueRef="db" functionName="consume" location="suggest_test.go:*" didYouMean="DB (provideDB)"`
+	}).RunParallel(t)
+
+	tc.WithCallback("0", func(t *testing.T, c *C) {
+		c.valueNames = []string{"LOGGER", "CONFIG"}
+		c.valueRef = "DATABASE"
+		c.errStr = ErrValueNotFound.Error() + `; valueRef="DATABASE" functionName="consume" location="suggest_test.go:*"`
+	}).RunParallel(t)
+}
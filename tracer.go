@@ -0,0 +1,65 @@
+package di
+
+import "context"
+
+// Tracer starts spans for Program.Run()/Program.Clean(). It is shaped after OpenTelemetry-style
+// APIs, so that an adapter for such an API is a thin wrapper.
+//
+// Program.Run() opens a span named "di.run", and a child span per body or hook callback of
+// DI Functions, the context carrying the child span is given to the body or the hook callback.
+// Program.Clean() opens a span named "di.clean", and a child span per cleanup.
+type Tracer interface {
+	// Start starts a span as a child of the span carried by the given context, if any, and
+	// returns a context carrying the new span.
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a span started by Tracer.
+type Span interface {
+	// SetAttributes sets the given attributes to the span.
+	SetAttributes(attributes ...Attribute)
+
+	// RecordError records the given error as a failure of the span.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// Attribute is a key/value pair of a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Keys of attributes set to spans.
+const (
+	AttributeFunction   = "di.function"
+	AttributeLocation   = "di.location"
+	AttributeValueRef   = "di.value_ref"
+	AttributeValueNames = "di.value_names"
+)
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string) (context.Context, Span) { return ctx, nopSpan{} }
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute) {}
+func (nopSpan) RecordError(error)          {}
+func (nopSpan) End()                       {}
+
+func (p *Program) tracer() Tracer {
+	if p.RunOptions.Tracer == nil {
+		return nopTracer{}
+	}
+	return p.RunOptions.Tracer
+}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
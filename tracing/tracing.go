@@ -0,0 +1,133 @@
+// Package tracing provides an in-memory Tracer of Program for tests.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-tk/di"
+)
+
+// Recorder is a Tracer recording all spans in memory.
+type Recorder struct {
+	mutex sync.Mutex
+	spans []*RecordedSpan
+}
+
+var _ di.Tracer = (*Recorder)(nil)
+
+// RecordedSpan is a span recorded by Recorder.
+type RecordedSpan struct {
+	recorder *Recorder
+
+	Name       string
+	Parent     *RecordedSpan
+	Attributes []di.Attribute
+	Errs       []error
+	IsEnded    bool
+}
+
+var _ di.Span = (*RecordedSpan)(nil)
+
+type spanKey struct{}
+
+// Start implements Tracer.Start.
+func (r *Recorder) Start(ctx context.Context, spanName string) (context.Context, di.Span) {
+	parent, _ := ctx.Value(spanKey{}).(*RecordedSpan)
+	span := &RecordedSpan{
+		recorder: r,
+		Name:     spanName,
+		Parent:   parent,
+	}
+	r.mutex.Lock()
+	r.spans = append(r.spans, span)
+	r.mutex.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Spans returns all spans recorded, in the order in which spans are started.
+func (r *Recorder) Spans() []*RecordedSpan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]*RecordedSpan(nil), r.spans...)
+}
+
+// String returns a tree of spans recorded, one span per line, children are indented
+// under their parents.
+func (r *Recorder) String() string {
+	spans := r.Spans()
+	var builder strings.Builder
+	var write func(parent *RecordedSpan, depth int)
+	write = func(parent *RecordedSpan, depth int) {
+		for _, span := range spans {
+			if span.Parent != parent {
+				continue
+			}
+			builder.WriteString(strings.Repeat("  ", depth))
+			builder.WriteString(span.String())
+			builder.WriteByte('\n')
+			write(span, depth+1)
+		}
+	}
+	write(nil, 0)
+	return builder.String()
+}
+
+// SpanFromContext returns the span carried by the given context, or nil if none.
+func SpanFromContext(ctx context.Context) *RecordedSpan {
+	span, _ := ctx.Value(spanKey{}).(*RecordedSpan)
+	return span
+}
+
+// SetAttributes implements Span.SetAttributes.
+func (rs *RecordedSpan) SetAttributes(attributes ...di.Attribute) {
+	rs.recorder.mutex.Lock()
+	defer rs.recorder.mutex.Unlock()
+	rs.Attributes = append(rs.Attributes, attributes...)
+}
+
+// RecordError implements Span.RecordError.
+func (rs *RecordedSpan) RecordError(err error) {
+	rs.recorder.mutex.Lock()
+	defer rs.recorder.mutex.Unlock()
+	rs.Errs = append(rs.Errs, err)
+}
+
+// End implements Span.End.
+func (rs *RecordedSpan) End() {
+	rs.recorder.mutex.Lock()
+	defer rs.recorder.mutex.Unlock()
+	rs.IsEnded = true
+}
+
+// Attribute returns the value of the attribute with the given key, or "" if none.
+func (rs *RecordedSpan) Attribute(key string) string {
+	rs.recorder.mutex.Lock()
+	defer rs.recorder.mutex.Unlock()
+	for _, attribute := range rs.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return ""
+}
+
+// String returns the span in the form of "name key=value ... err=... (not ended)".
+func (rs *RecordedSpan) String() string {
+	rs.recorder.mutex.Lock()
+	defer rs.recorder.mutex.Unlock()
+	var builder strings.Builder
+	builder.WriteString(rs.Name)
+	for _, attribute := range rs.Attributes {
+		fmt.Fprintf(&builder, " %s=%q", attribute.Key, attribute.Value)
+	}
+	for _, err := range rs.Errs {
+		fmt.Fprintf(&builder, " err=%q", err.Error())
+	}
+	if !rs.IsEnded {
+		builder.WriteString(" (not ended)")
+	}
+	return builder.String()
+}
@@ -0,0 +1,47 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/go-tk/di"
+	. "github.com/go-tk/di/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	var program di.Program
+	var recorder Recorder
+	program.RunOptions.Tracer = &recorder
+	var x int
+	var bodySpan *RecordedSpan
+	program.MustNewFunction(
+		di.Result("X", &x),
+		di.Body(func(ctx context.Context) error {
+			bodySpan = SpanFromContext(ctx)
+			return nil
+		}),
+		di.Cleanup(func() {}),
+	)
+	program.MustNewFunction(
+		di.Hook("X", &x, func(context.Context) error { return errors.New("foo") }),
+		di.Body(func(context.Context) error { return nil }),
+	)
+	assert.Error(t, program.Run(context.Background()))
+	program.Clean()
+	if assert.NotNil(t, bodySpan) {
+		assert.Equal(t, "di.body", bodySpan.Name)
+		assert.Equal(t, "X", bodySpan.Attribute(di.AttributeValueNames))
+	}
+	assert.Equal(t, "di.body", recorder.Spans()[1].Name)
+	trace := regexp.MustCompile(`[^"\s\\]+\.go:\d+`).ReplaceAllString(recorder.String(), `*`)
+	assert.Equal(t, `di.run err="do callback; functionName=\"github.com/go-tk/di/tracing_test.TestRecorder\" valueRef=\"X\" location=\"*\": foo"
+  di.body di.function="github.com/go-tk/di/tracing_test.TestRecorder" di.location="*" di.value_names=""
+  di.body di.function="github.com/go-tk/di/tracing_test.TestRecorder" di.location="*" di.value_names="X"
+  di.hook di.function="github.com/go-tk/di/tracing_test.TestRecorder" di.location="*" di.value_ref="X" err="foo"
+di.clean
+  di.cleanup di.function="github.com/go-tk/di/tracing_test.TestRecorder" di.location="*" di.value_names="X"
+`, trace)
+}
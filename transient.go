@@ -0,0 +1,73 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// Transient marks a DI Function as transient, whose body is called for each argument referencing
+// its results, rather than once, so that each consumer receives fresh values. The name of the
+// consuming DI Function is available through ConsumerName(). The cleanup, if any, is called for
+// each call to the body, with the results of that call.
+//
+// A transient DI Function must have results, and can neither have hooks nor be hooked.
+// Program.RunReport() does not cover calls to bodies of transient DI Functions.
+func Transient() FunctionBuilder {
+	return func(function *function, program *Program) error {
+		function.IsTransient = true
+		return nil
+	}
+}
+
+type consumerNameKey struct{}
+
+// ConsumerName returns the name of the DI Function consuming the results of a transient DI
+// Function, given the context passed to the body of the transient DI Function, or "" if none.
+func ConsumerName(ctx context.Context) string {
+	consumerName, _ := ctx.Value(consumerNameKey{}).(string)
+	return consumerName
+}
+
+// functionCall records a call to the body of a DI Function, for Program.Clean().
+type functionCall struct {
+	FunctionIndex int
+
+	// ResultValues are copies of the results, for transient DI Functions with cleanups only.
+	ResultValues []reflect.Value
+}
+
+// callTransient calls the body of the given transient DI Function for the given consumer.
+func (p *Program) callTransient(ctx context.Context, function *function, consumer *function) error {
+	ctx = context.WithValue(ctx, consumerNameKey{}, consumer.Name)
+	if err := p.setArguments(ctx, function); err != nil {
+		return err
+	}
+	if err := p.callBody(ctx, function, &functionTiming{FunctionIndex: function.Index}); err != nil {
+		return err
+	}
+	functionCall := functionCall{FunctionIndex: function.Index}
+	if function.Cleanup != nil {
+		for _, resultIndex := range function.ResultIndexes {
+			value := p.results[resultIndex].Value
+			resultValue := reflect.New(value.Type()).Elem()
+			resultValue.Set(value)
+			functionCall.ResultValues = append(functionCall.ResultValues, resultValue)
+		}
+	}
+	p.mutex.Lock()
+	p.functionCalls = append(p.functionCalls, functionCall)
+	p.mutex.Unlock()
+	return nil
+}
+
+var (
+	// ErrTransientValueHooked is returned by Program.Validate()/Program.Run() when a value used by
+	// Hook() is produced by a transient DI Function.
+	ErrTransientValueHooked = errors.New("di: transient value hooked")
+
+	// ErrTransientValueInherited is returned by Program.Validate()/Program.Run() of a child Program
+	// when a value used by Argument()/Hook() is inherited from a transient DI Function, as the
+	// transient DI Function belongs to the ancestor and cannot be called for the child Program.
+	ErrTransientValueInherited = errors.New("di: transient value inherited")
+)
@@ -0,0 +1,107 @@
+package di_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/go-tk/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransient(t *testing.T) {
+	var p Program
+	var log []string
+	var prefix, logger, logger2 string
+	p.MustDoNewFunction("providePrefix", Result("PREFIX", &prefix), Body(func(ctx context.Context) error {
+		prefix = "logger for "
+		return nil
+	}))
+	p.MustDoNewFunction("provideLogger", Argument("PREFIX", &prefix), Result("LOGGER", &logger), Body(func(ctx context.Context) error {
+		logger = prefix + ConsumerName(ctx)
+		log = append(log, "new "+logger)
+		return nil
+	}), Cleanup(func() { log = append(log, "close "+logger) }), Transient())
+	p.MustDoNewFunction("provideTaggedLogger", Argument("LOGGER", &logger2), Result("TAGGED_LOGGER", &logger2), Body(func(ctx context.Context) error {
+		logger2 = "[" + ConsumerName(ctx) + "] " + logger2
+		return nil
+	}), Transient())
+	var a, b string
+	var c *string
+	p.MustDoNewFunction("serveA", Argument("LOGGER", &a), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("serveB", Argument("LOGGER", &b), Argument("LOGGER", &c), Body(func(context.Context) error { return nil }))
+	var d string
+	p.MustDoNewFunction("serveD", Argument("TAGGED_LOGGER", &d), Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+	assert.Equal(t, "logger for serveA", a)
+	assert.Equal(t, "logger for serveB", b)
+	if assert.NotNil(t, c) {
+		assert.Equal(t, "logger for serveB", *c)
+		assert.NotSame(t, &logger, c)
+	}
+	assert.Equal(t, "[serveD] logger for provideTaggedLogger", d)
+	assert.Equal(t, []string{
+		"new logger for serveA",
+		"new logger for serveB",
+		"new logger for serveB",
+		"new logger for provideTaggedLogger",
+	}, log)
+	assert.Len(t, p.State().PendingCleanupIndexes, 4)
+
+	log = nil
+	p.Clean()
+	assert.Equal(t, []string{
+		"close logger for provideTaggedLogger",
+		"close logger for serveB",
+		"close logger for serveB",
+		"close logger for serveA",
+	}, log)
+	assert.Empty(t, p.State().PendingCleanupIndexes)
+}
+
+func TestTransient_Errors(t *testing.T) {
+	var p Program
+	var x int
+	err := p.DoNewFunction("foo", Body(func(context.Context) error { return nil }), Transient())
+	assertEqualError(t, err, `di: invalid transient: no result; functionName="foo" location="transient_test.go:*"`)
+	err = p.DoNewFunction("foo", Result("X", &x), Hook("Y", &x, func(context.Context) error { return nil }),
+		Body(func(context.Context) error { return nil }), Transient())
+	assert.ErrorIs(t, err, ErrInvalidTransient)
+
+	p = Program{}
+	p.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }), Transient())
+	p.MustDoNewFunction("watchX", Hook("X", &x, func(context.Context) error { return nil }), Body(func(context.Context) error { return nil }))
+	err = p.Validate()
+	assert.ErrorIs(t, err, ErrTransientValueHooked)
+	assertEqualError(t, err, `di: transient value hooked; valueRef="X" producerName="provideX" functionName="watchX" location="transient_test.go:*"`)
+}
+
+func TestTransient_ErrorsRollback(t *testing.T) {
+	var p Program
+	var x, z int
+	err := p.DoNewFunction("foo", Result("X", &x), Hook("Z", &z, func(context.Context) error { return nil }),
+		Body(func(context.Context) error { return nil }), Transient())
+	assert.ErrorIs(t, err, ErrInvalidTransient)
+	p.MustDoNewFunction("provideX", Result("X", &x), Body(func(context.Context) error { return nil }))
+	p.MustDoNewFunction("useX", Argument("X", &x), Body(func(context.Context) error { return nil }))
+	assert.NoError(t, p.Validate())
+	assert.Len(t, p.Graph().Functions, 2)
+}
+
+func TestTransient_Inherited(t *testing.T) {
+	var p Program
+	var logger, a string
+	p.MustDoNewFunction("provideLogger", Result("LOGGER", &logger), Body(func(ctx context.Context) error {
+		logger = "logger for " + ConsumerName(ctx)
+		return nil
+	}), Transient())
+	p.MustDoNewFunction("serveA", Argument("LOGGER", &a), Body(func(context.Context) error { return nil }))
+	p.MustRun(context.Background())
+
+	scope := p.NewScope()
+	var b string
+	scope.MustDoNewFunction("serveB", Argument("LOGGER", &b), Body(func(context.Context) error { return nil }))
+	err := scope.Run(context.Background())
+	assert.ErrorIs(t, err, ErrTransientValueInherited)
+	assertEqualError(t, err, `di: transient value inherited; valueRef="LOGGER" producerName="provideLogger" functionName="serveB" location="transient_test.go:*"`)
+	assert.Equal(t, "", b)
+}